@@ -0,0 +1,145 @@
+// Command voxthumb renders voxel models without a GPU and writes PNG
+// thumbnails, sprite sheets or animated turntable GIFs.
+//
+// Usage:
+//
+//	voxthumb [flags] model.vox [model.kv6 ...]
+//
+// The output files are written to the directory given by -out and are named
+// after the model files.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	size       = flag.Int("size", 128, "width and height of a rendered view in pixels")
+	yaw        = flag.Float64("yaw", 30, "rotation of the model around the vertical axis in degrees")
+	pitch      = flag.Float64("pitch", 30, "angle the camera looks down on the model in degrees")
+	mode       = flag.String("mode", "png", "output mode: png, sheet or gif")
+	frames     = flag.Int("frames", 8, "number of views for sheet and gif mode")
+	cols       = flag.Int("cols", 0, "number of columns of the sprite sheet (default all views in one row)")
+	delay      = flag.Int("delay", 10, "delay between gif frames in 100ths of a second")
+	background = flag.String("bg", "", "background color as RRGGBB or RRGGBBAA (default transparent)")
+	outDir     = flag.String("out", ".", "output directory")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] model...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *size <= 0 || *frames <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch *mode {
+	case "png", "sheet", "gif":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown mode %q\n", *mode)
+		os.Exit(2)
+	}
+	bg, err := parseColor(*background)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, file := range flag.Args() {
+		if err := thumbnail(file, bg); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func thumbnail(file string, bg color.Color) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	model, err := loadModel(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	r := newRenderer(model, *size, bg)
+	name := filepath.Join(*outDir, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+	switch *mode {
+	case "png":
+		return writePNG(name+".png", r.render(view{Yaw: *yaw, Pitch: *pitch}))
+	case "sheet":
+		return writePNG(name+".png", spriteSheet(r.turntable(*frames, *pitch), *cols))
+	case "gif":
+		return writeGIF(name+".gif", r.turntable(*frames, *pitch), *delay)
+	}
+	return fmt.Errorf("unknown mode %q", *mode)
+}
+
+func writePNG(file string, img image.Image) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeGIF(file string, frames []*image.RGBA, delay int) error {
+	anim := &gif.GIF{
+		Image:    toPaletted(frames),
+		Delay:    make([]int, len(frames)),
+		Disposal: make([]byte, len(frames)),
+	}
+	for i := range frames {
+		anim.Delay[i] = delay
+		anim.Disposal[i] = gif.DisposalBackground
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseColor parses colors in the form RRGGBB or RRGGBBAA. An empty string is
+// transparent.
+func parseColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	if s == "" {
+		return color.Transparent, nil
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 8 || err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	c := color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}
+	return c, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"io"
+	"sort"
+
+	"github.com/boombuler/voxel/kv6"
+	"github.com/boombuler/voxel/magica"
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+	"github.com/boombuler/voxel/rendering/software"
+)
+
+var errUnknownFormat = errors.New("unknown voxel file format")
+
+// loadModel reads a model in any of the supported formats. The format is
+// detected by the header of the file.
+func loadModel(rd io.Reader) (rendering.Chunk, error) {
+	br := bufio.NewReader(rd)
+	head, err := br.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	switch string(head) {
+	case "VOX ":
+		return magica.Read(br)
+	case "Kvxl":
+		return kv6.Read(br)
+	}
+	return nil, errUnknownFormat
+}
+
+// view describes the camera looking at the center of a model.
+type view struct {
+	// Yaw rotates the model around the Y axis, Pitch tilts the camera down.
+	Yaw, Pitch float64
+}
+
+// transforms returns the model matrix centering and rotating the model and
+// an orthographic projection fitting the model into the image.
+func (v view) transforms(size mgl.Vec3I) (model, proj mgl.Mat4) {
+	center := size.Vec3().Mul(0.5)
+	model = mgl.Identity().
+		Rotate(mgl.Degree(v.Pitch).ToRadian(), mgl.Vec3{1, 0, 0}).
+		Rotate(mgl.Degree(v.Yaw).ToRadian(), mgl.Vec3{0, 1, 0}).
+		TranslateVec3(center.Mul(-1))
+
+	r := center.Len()
+	if r == 0 {
+		r = 1
+	}
+	return model, mgl.Ortho(-r, r, -r, r, -r, r)
+}
+
+// renderer renders a meshed model from different views.
+type renderer struct {
+	mesh       []rendering.VertexF
+	size       mgl.Vec3I
+	imageSize  int
+	background color.Color
+}
+
+func newRenderer(c rendering.Chunk, imageSize int, background color.Color) *renderer {
+	mesh, _ := rendering.CreateMeshFromChunk(c, rendering.Options{})
+	return &renderer{
+		mesh:       mesh,
+		size:       c.Size(),
+		imageSize:  imageSize,
+		background: background,
+	}
+}
+
+func (r *renderer) render(v view) *image.RGBA {
+	model, proj := v.transforms(r.size)
+	rast := software.NewRasterizer(r.imageSize, r.imageSize)
+	rast.Clear(r.background)
+	rast.DrawMesh(software.TransformMesh(r.mesh, model), proj)
+	return rast.Image()
+}
+
+// turntable renders the given number of frames rotating the model once.
+func (r *renderer) turntable(frames int, pitch float64) []*image.RGBA {
+	res := make([]*image.RGBA, frames)
+	for i := range res {
+		res[i] = r.render(view{Yaw: 360 * float64(i) / float64(frames), Pitch: pitch})
+	}
+	return res
+}
+
+// spriteSheet arranges the frames in a grid with the given number of columns.
+func spriteSheet(frames []*image.RGBA, cols int) *image.RGBA {
+	if len(frames) == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	if cols <= 0 || cols > len(frames) {
+		cols = len(frames)
+	}
+	rows := (len(frames) + cols - 1) / cols
+	fs := frames[0].Bounds().Size()
+	sheet := image.NewRGBA(image.Rect(0, 0, fs.X*cols, fs.Y*rows))
+	for i, f := range frames {
+		at := image.Pt((i%cols)*fs.X, (i/cols)*fs.Y)
+		draw.Draw(sheet, f.Bounds().Add(at), f, f.Bounds().Min, draw.Src)
+	}
+	return sheet
+}
+
+// gifPalette returns the colors of the frames if they fit into a GIF palette
+// and the Plan9 palette otherwise. The first entry is transparent.
+func gifPalette(frames []*image.RGBA) color.Palette {
+	colors := make(map[color.RGBA]struct{})
+	for _, f := range frames {
+		for i := 0; i < len(f.Pix); i += 4 {
+			c := color.RGBA{f.Pix[i], f.Pix[i+1], f.Pix[i+2], 0xFF}
+			if f.Pix[i+3] < 0x80 {
+				continue
+			}
+			colors[c] = struct{}{}
+			if len(colors) > 255 {
+				return append(color.Palette{color.Transparent}, palette.Plan9[:255]...)
+			}
+		}
+	}
+	sorted := make([]color.RGBA, 0, len(colors))
+	for c := range colors {
+		sorted = append(sorted, c)
+	}
+	// sort the colors so the same frames always result in the same palette
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		if a.G != b.G {
+			return a.G < b.G
+		}
+		return a.B < b.B
+	})
+	res := color.Palette{color.Transparent}
+	for _, c := range sorted {
+		res = append(res, c)
+	}
+	return res
+}
+
+// toPaletted converts the frames to paletted images for GIF encoding. Pixels
+// which are mostly transparent use the transparent palette entry.
+func toPaletted(frames []*image.RGBA) []*image.Paletted {
+	pal := gifPalette(frames)
+	res := make([]*image.Paletted, len(frames))
+	for i, f := range frames {
+		p := image.NewPaletted(f.Bounds(), pal)
+		b := f.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := f.RGBAAt(x, y)
+				if c.A < 0x80 {
+					p.SetColorIndex(x, y, 0)
+					continue
+				}
+				c.A = 0xFF
+				p.SetColorIndex(x, y, uint8(pal[1:].Index(c)+1))
+			}
+		}
+		res[i] = p
+	}
+	return res
+}
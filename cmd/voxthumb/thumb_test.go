@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering/renderingtest"
+)
+
+func Test_LoadModelUnknownFormat(t *testing.T) {
+	if _, err := loadModel(bytes.NewReader([]byte("nope, not a model"))); err != errUnknownFormat {
+		t.Errorf("Expected errUnknownFormat but got %v", err)
+	}
+}
+
+func Test_RenderCentered(t *testing.T) {
+	r := newRenderer(renderingtest.FilledChunk(mgl.Vec3I{4, 4, 4}, renderingtest.Red), 32, color.Transparent)
+	for _, v := range []view{{0, 0}, {45, 30}, {200, -20}} {
+		img := r.render(v)
+		if img.RGBAAt(16, 16).A != 0xFF {
+			t.Errorf("Expected the model at the center of view %v", v)
+		}
+		for _, p := range []image.Point{{0, 0}, {31, 0}, {0, 31}, {31, 31}} {
+			if img.RGBAAt(p.X, p.Y).A != 0 {
+				t.Errorf("Expected corner %v of view %v to be transparent", p, v)
+			}
+		}
+	}
+}
+
+func Test_SpriteSheet(t *testing.T) {
+	var frames []*image.RGBA
+	for i := 0; i < 5; i++ {
+		f := image.NewRGBA(image.Rect(0, 0, 4, 3))
+		f.SetRGBA(0, 0, color.RGBA{uint8(i), 0, 0, 0xFF})
+		frames = append(frames, f)
+	}
+	sheet := spriteSheet(frames, 2)
+	if sz := sheet.Bounds().Size(); sz != image.Pt(8, 9) {
+		t.Fatalf("Expected a sheet of 8x9 pixels but got %v", sz)
+	}
+	for i := range frames {
+		if c := sheet.RGBAAt((i%2)*4, (i/2)*3); c.R != uint8(i) {
+			t.Errorf("Expected frame %d at cell %d but got %d", i, i, c.R)
+		}
+	}
+}
+
+func Test_GIFPalette(t *testing.T) {
+	r := newRenderer(renderingtest.FilledChunk(mgl.Vec3I{2, 2, 2}, renderingtest.Voxel{G: 255, A: 255}), 16, color.Transparent)
+	frames := toPaletted(r.turntable(3, 30))
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames but got %d", len(frames))
+	}
+	for i, f := range frames {
+		if f.ColorIndexAt(0, 0) != 0 {
+			t.Errorf("Expected the corner of frame %d to be transparent", i)
+		}
+		if idx := f.ColorIndexAt(8, 8); idx == 0 {
+			t.Errorf("Expected the center of frame %d to be opaque", i)
+		}
+	}
+}
+
+func Test_GIFPaletteSorted(t *testing.T) {
+	f := image.NewRGBA(image.Rect(0, 0, 16, 1))
+	for x := 0; x < 16; x++ {
+		f.SetRGBA(x, 0, color.RGBA{uint8(16 - x), uint8(x % 3), 0, 0xFF})
+	}
+	pal := gifPalette([]*image.RGBA{f})
+	if len(pal) != 17 {
+		t.Fatalf("Expected 17 palette entries but got %d", len(pal))
+	}
+	for i := 2; i < len(pal); i++ {
+		if p, c := pal[i-1].(color.RGBA), pal[i].(color.RGBA); p.R > c.R {
+			t.Errorf("Palette is not sorted at %d: %v after %v", i, c, p)
+		}
+	}
+}
+
+func Test_ParseColor(t *testing.T) {
+	tests := map[string]color.Color{
+		"":          color.Transparent,
+		"ff8000":    color.NRGBA{255, 128, 0, 255},
+		"#00ff0080": color.NRGBA{0, 255, 0, 128},
+	}
+	for s, exp := range tests {
+		c, err := parseColor(s)
+		if err != nil || c != exp {
+			t.Errorf("parseColor(%q) = %v, %v expected %v", s, c, err, exp)
+		}
+	}
+	if _, err := parseColor("red"); err == nil {
+		t.Error("Expected an error for an invalid color")
+	}
+}
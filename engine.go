@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"runtime"
 
+	"github.com/boombuler/voxel/mgl"
 	"github.com/boombuler/voxel/rendering"
-	"github.com/go-gl-legacy/gl"
+	"github.com/boombuler/voxel/rendering/legacygl"
 	"github.com/go-gl/glfw/v3.0/glfw"
 )
 
@@ -21,11 +23,75 @@ type Options struct {
 
 type Engine struct {
 	RenderObjects []rendering.Object
+	// Backend draws the render objects.
+	Backend rendering.Backend
+	// Mesher meshes chunks in the background. The results are uploaded
+	// before each frame is rendered.
+	Mesher         *rendering.Mesher
+	Window         *glfw.Window
+	camera         *camera
+	viewportHeight int
+}
+
+// objectScale is the size of a voxel of the render objects in world units.
+const objectScale = 0.01
+
+// Raycast returns the first voxel of the render objects hit by the ray. The
+// ray is given in world coordinates.
+func (e *Engine) Raycast(origin, dir mgl.Vec3, maxDist float32) (rendering.RaycastHit, bool) {
+	return rendering.RaycastObjects(e.RenderObjects, origin, dir, maxDist, objectScale)
+}
+
+// meshPriority returns the distance of the object to the camera, so the
+// meshes of near objects are finished first.
+func (e *Engine) meshPriority(obj rendering.Object) float32 {
+	if e.camera == nil {
+		return 0
+	}
+	bounds := mgl.AABBFromSize(obj.Position(), obj.Size().Mul(objectScale))
+	return bounds.Center().Sub(e.camera.pos).Len()
+}
+
+// reprioritizeMeshes updates the priorities of the pending meshes after the
+// camera moved. The meshes have to be tagged with their render object.
+func (e *Engine) reprioritizeMeshes() {
+	e.Mesher.Reprioritize(func(req rendering.MeshRequest) float32 {
+		if am, ok := req.Tag.(*rendering.AsyncMesh); ok {
+			if obj, ok := am.Tag().(rendering.Object); ok {
+				return e.meshPriority(obj)
+			}
+		}
+		return req.Priority
+	})
+}
+
+// uploadMeshes passes the meshes finished by the mesher to their renderers.
+func (e *Engine) uploadMeshes() {
+	for {
+		select {
+		case res := <-e.Mesher.Results():
+			if am, ok := res.Tag.(*rendering.AsyncMesh); ok {
+				am.SetResult(res)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// projectedSize returns the approximated size in pixels of a sphere on the screen
+func (e *Engine) projectedSize(modelView, projection mgl.Mat4, center mgl.Vec3, radius float32) float32 {
+	dist := -modelView.MulVec4(center.Vec4(1)).Z()
+	if dist <= radius {
+		return math.MaxFloat32
+	}
+	return radius * projection[5] * float32(e.viewportHeight) / dist
 }
 
 func (e *Engine) renderObjects(fr *rendering.Frustum) {
 	visibleObjects := make(chan rendering.Object)
-	scaleF := float32(0.01)
+	scaleF := float32(objectScale)
+	modelView, projection := e.Backend.ModelView(), e.Backend.Projection()
 	go func() {
 		for _, obj := range e.RenderObjects {
 			renderer := obj.Renderer()
@@ -36,16 +102,26 @@ func (e *Engine) renderObjects(fr *rendering.Frustum) {
 		close(visibleObjects)
 	}()
 	for obj := range visibleObjects {
-		gl.PushMatrix()
 		p := obj.Position()
-		gl.Translatef(p.X(), p.Y(), p.Z())
-		gl.Scalef(scaleF, scaleF, scaleF)
+		e.Backend.PushTransform(mgl.Identity().TranslateVec3(p).Scale(scaleF, scaleF, scaleF))
 		r := obj.Renderer()
-		r.Render()
-		gl.PopMatrix()
+		if lr, ok := r.(rendering.LODRenderer); ok {
+			bounds := mgl.AABBFromSize(p, obj.Size().Mul(scaleF))
+			lr.RenderLOD(e.projectedSize(modelView, projection, bounds.Center(), bounds.Size().Len()/2))
+		} else {
+			r.Render()
+		}
+		e.Backend.PopTransform()
 	}
 }
 
+// renderFrame uploads the finished meshes and draws the scene.
+func (e *Engine) renderFrame(fr *rendering.Frustum) {
+	e.uploadMeshes()
+	e.Backend.Clear()
+	e.renderObjects(fr)
+}
+
 func fallBackErrorCallback(err glfw.ErrorCode, desc string) {
 	fmt.Printf("%v: %v\n", err, desc)
 }
@@ -74,24 +150,21 @@ func StartEngine(options Options) {
 	wnd.MakeContextCurrent()
 	glfw.SwapInterval(1)
 
-	gl.Init()
-	gl.Enable(gl.DEPTH_TEST)
-	gl.ClearColor(0, 0, 0, 0)
-	gl.ClearDepth(1)
-	gl.DepthFunc(gl.LEQUAL)
+	backend := legacygl.New()
+	backend.Init(options.WindowWidth, options.WindowHeight)
 	cam := NewCamera()
 
-	gl.Viewport(0, 0, options.WindowWidth, options.WindowHeight)
-	gl.MatrixMode(gl.PROJECTION)
-	gl.LoadIdentity()
-
-	gl.MatrixMode(gl.MODELVIEW)
-	gl.LoadIdentity()
 	wnd.SetInputMode(glfw.Cursor, glfw.CursorDisabled)
 	frustum := rendering.NewFrustum()
 	engine := &Engine{
-		RenderObjects: make([]rendering.Object, 0),
+		RenderObjects:  make([]rendering.Object, 0),
+		Backend:        backend,
+		Mesher:         rendering.NewMesher(n),
+		Window:         wnd,
+		camera:         cam,
+		viewportHeight: options.WindowHeight,
 	}
+	defer engine.Mesher.Close()
 	if options.LoadFunc != nil {
 		options.LoadFunc(engine)
 	}
@@ -101,14 +174,12 @@ func StartEngine(options Options) {
 		nTime := glfw.GetTime()
 		dt := nTime - curTime
 		curTime = nTime
-		if cam.update(wnd, dt) {
-			frustum.Update()
+		if projection, modelView, moved := cam.update(wnd, backend, dt); moved {
+			frustum.Update(projection, modelView)
+			engine.reprioritizeMeshes()
 		}
 		options.UpdateFunc(dt, engine)
-		// Draw Scene
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
-		engine.renderObjects(frustum)
+		engine.renderFrame(frustum)
 
 		// Finish
 		wnd.SwapBuffers()
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+	"github.com/boombuler/voxel/rendering/renderingtest"
+)
+
+type testObject struct {
+	pos      mgl.Vec3
+	renderer rendering.Renderer
+}
+
+func (o *testObject) Position() mgl.Vec3 {
+	return o.pos
+}
+
+func (o *testObject) Size() mgl.Vec3 {
+	return mgl.Vec3{100, 100, 100}
+}
+
+func (o *testObject) Renderer() rendering.Renderer {
+	return o.renderer
+}
+
+func newTestEngine() (*Engine, *rendering.RecordingBackend, *rendering.Frustum) {
+	b := rendering.NewRecordingBackend()
+	e := &Engine{
+		Backend:        b,
+		Mesher:         rendering.NewMesher(1),
+		viewportHeight: 100,
+	}
+	fr := rendering.NewFrustum()
+	fr.Update(b.Projection(), b.ModelView())
+	return e, b, fr
+}
+
+func Test_EngineRenderObjects(t *testing.T) {
+	e, b, fr := newTestEngine()
+	defer e.Mesher.Close()
+
+	draw := func() { b.DrawQuads(nil) }
+	e.RenderObjects = []rendering.Object{
+		&testObject{mgl.Vec3{-0.5, -0.5, -0.5}, rendering.RenderFunc(draw)},
+		&testObject{mgl.Vec3{5, 0, 0}, rendering.RenderFunc(draw)},
+		&testObject{mgl.Vec3{0, 0, 0}, nil},
+	}
+	e.renderFrame(fr)
+
+	if b.Count(rendering.OpClear) != 1 {
+		t.Errorf("Frame was not cleared")
+	}
+	if b.Count(rendering.OpDrawQuads) != 1 {
+		t.Fatalf("Only the visible object should be drawn: %v", b.Calls)
+	}
+	call := b.Calls[len(b.Calls)-1]
+	expected := mgl.Identity().Translate(-0.5, -0.5, -0.5).Scale(0.01, 0.01, 0.01)
+	if call.ModelView != expected {
+		t.Errorf("Invalid transform of the object:\n%v", call.ModelView)
+	}
+	if b.ModelView() != mgl.Identity() {
+		t.Errorf("Transform was not restored")
+	}
+}
+
+func Test_EngineUploadMeshes(t *testing.T) {
+	e, b, fr := newTestEngine()
+	defer e.Mesher.Close()
+
+	tc := renderingtest.FilledChunk(mgl.Vec3I{1, 1, 1}, renderingtest.White)
+	am := rendering.NewAsyncMesh(e.Mesher, rendering.MeshRequest{Chunk: tc, Options: rendering.Options{Backend: b}})
+	e.RenderObjects = []rendering.Object{&testObject{mgl.Vec3{-0.5, -0.5, -0.5}, am}}
+	deadline := time.Now().Add(5 * time.Second)
+	for !am.Ready() && time.Now().Before(deadline) {
+		e.renderFrame(fr)
+		time.Sleep(time.Millisecond)
+	}
+	if !am.Ready() {
+		t.Fatal("Mesh was never uploaded")
+	}
+	b.Reset()
+	e.renderFrame(fr)
+	if b.Count(rendering.OpDrawMesh) != 1 {
+		t.Errorf("Uploaded mesh was not drawn: %v", b.Calls)
+	}
+	am.Close()
+	if b.LiveMeshes() != 0 {
+		t.Errorf("Mesh was not deleted")
+	}
+}
+
+type testChunkObject struct {
+	testObject
+	chunk rendering.Chunk
+}
+
+func (o *testChunkObject) Chunk() rendering.Chunk {
+	return o.chunk
+}
+
+func Test_EngineRaycast(t *testing.T) {
+	e, _, _ := newTestEngine()
+	defer e.Mesher.Close()
+
+	obj := &testChunkObject{testObject{pos: mgl.Vec3{-0.5, -0.5, -0.5}}, renderingtest.FilledChunk(mgl.Vec3I{100, 100, 100}, renderingtest.White)}
+	e.RenderObjects = []rendering.Object{obj}
+
+	hit, ok := e.Raycast(mgl.Vec3{0.001, 0.001, 5}, mgl.Vec3{0, 0, -1}, 10)
+	if !ok || hit.Object != obj {
+		t.Fatalf("Expected to hit the object but got %v", hit)
+	}
+	if hit.Pos != (mgl.Vec3I{50, 50, 99}) || hit.Normal != (mgl.Vec3I{0, 0, 1}) || !mgl.FloatEqualThreshold(hit.Distance, 4.5, 1e-5) {
+		t.Errorf("Wrong hit %v %v at %v", hit.Pos, hit.Normal, hit.Distance)
+	}
+	if _, ok := e.Raycast(mgl.Vec3{0, 0, 5}, mgl.Vec3{0, 0, 1}, 10); ok {
+		t.Errorf("Expected a ray pointing away to miss")
+	}
+}
+
+func Test_EngineMeshPriority(t *testing.T) {
+	e, _, _ := newTestEngine()
+	defer e.Mesher.Close()
+	e.camera = NewCamera()
+
+	near := &testObject{pos: mgl.Vec3{-0.5, -0.5, -5}}
+	far := &testObject{pos: mgl.Vec3{-0.5, -0.5, 5}}
+	if pn, pf := e.meshPriority(near), e.meshPriority(far); pn >= pf {
+		t.Errorf("Near object should be meshed first got priorities %v and %v", pn, pf)
+	}
+}
+
+// blockingChunk blocks the meshing until release is closed.
+type blockingChunk struct {
+	*renderingtest.Chunk
+	started chan struct{}
+	release chan struct{}
+}
+
+func (bc *blockingChunk) At(pos mgl.Vec3I) rendering.Voxel {
+	select {
+	case <-bc.release:
+	default:
+		close(bc.started)
+		<-bc.release
+	}
+	return bc.Chunk.At(pos)
+}
+
+func Test_EngineReprioritizeMeshes(t *testing.T) {
+	e, b, _ := newTestEngine()
+	defer e.Mesher.Close()
+	e.camera = NewCamera()
+
+	opt := rendering.Options{Backend: b}
+	blocker := &blockingChunk{renderingtest.FilledChunk(mgl.Vec3I{1, 1, 1}, renderingtest.White), make(chan struct{}), make(chan struct{})}
+	rendering.NewAsyncMesh(e.Mesher, rendering.MeshRequest{Chunk: blocker, Options: opt})
+	<-blocker.started
+
+	near := &testObject{pos: mgl.Vec3{-0.5, -0.5, -5}}
+	far := &testObject{pos: mgl.Vec3{-0.5, -0.5, 5}}
+	tc := renderingtest.FilledChunk(mgl.Vec3I{1, 1, 1}, renderingtest.White)
+	nearMesh := rendering.NewAsyncMesh(e.Mesher, rendering.MeshRequest{Chunk: tc, Options: opt, Priority: 2, Tag: near})
+	farMesh := rendering.NewAsyncMesh(e.Mesher, rendering.MeshRequest{Chunk: tc, Options: opt, Priority: 1, Tag: far})
+	e.reprioritizeMeshes()
+	close(blocker.release)
+
+	<-e.Mesher.Results()
+	for _, expected := range []*rendering.AsyncMesh{nearMesh, farMesh} {
+		select {
+		case res := <-e.Mesher.Results():
+			if res.Tag != expected {
+				t.Errorf("Meshes should be ordered by the distance to the camera")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for mesh result")
+		}
+	}
+}
@@ -5,12 +5,16 @@ import (
 	kv6 "github.com/boombuler/voxel/magica"
 	"github.com/boombuler/voxel/mgl"
 	"github.com/boombuler/voxel/rendering"
+	"github.com/go-gl/glfw/v3.0/glfw"
+	"math"
 	"os"
 )
 
 var (
-	obj rendering.Renderer
-	cam *camera
+	obj     rendering.Renderer
+	cam     *camera
+	model   *ChunkObj
+	section = &sectionPlane{speed: 8}
 )
 
 func main() {
@@ -25,21 +29,122 @@ func main() {
 
 func LoadObjects(e *Engine) {
 
-	obj, err := loadModelFile()
+	obj, err := loadModelFile(e.Backend)
 	if err != nil {
 		panic(err.Error())
 	}
+	model = obj
+	section.height = obj.size.Y()
 	e.RenderObjects = append(e.RenderObjects, obj)
 }
 
 func Update(dt float64, e *Engine) {
-	// Nothing to do here :)
+	if section.update(e.Window, dt) {
+		model.remesh(e)
+	}
+	model.swapPending()
+}
+
+// sectionPlane is the clip plane used to inspect the interior of the model.
+// C toggles the cutaway, PageUp and PageDown move the plane and Left and
+// Right tilt it around the Z axis.
+type sectionPlane struct {
+	enabled bool
+	height  float32
+	tilt    float64
+	speed   float32
+	toggle  bool
+
+	// the state of the last mesh
+	meshed      bool
+	meshedLevel int
+	meshedTilt  int
+}
+
+func (sp *sectionPlane) plane(center mgl.Vec3) rendering.ClipPlane {
+	n := mgl.Vec3{float32(-math.Sin(sp.tilt)), float32(math.Cos(sp.tilt)), 0}
+	p := mgl.Vec3{center.X(), sp.height, center.Z()}
+	return rendering.ClipPlaneThrough(p, n)
+}
+
+// update handles the keys and returns true if the section changed so the
+// model has to be meshed again.
+func (sp *sectionPlane) update(w *glfw.Window, dt float64) bool {
+	pressed := w.GetKey(glfw.KeyC) == glfw.Press
+	if pressed && !sp.toggle {
+		sp.enabled = !sp.enabled
+	}
+	sp.toggle = pressed
+
+	if sp.enabled {
+		delta := float32(dt) * sp.speed
+		if w.GetKey(glfw.KeyPageUp) == glfw.Press {
+			sp.height += delta
+		}
+		if w.GetKey(glfw.KeyPageDown) == glfw.Press {
+			sp.height -= delta
+		}
+		if w.GetKey(glfw.KeyLeft) == glfw.Press {
+			sp.tilt += dt
+		}
+		if w.GetKey(glfw.KeyRight) == glfw.Press {
+			sp.tilt -= dt
+		}
+	}
+
+	// Only mesh again if the plane moved by a whole voxel or about 5 degrees.
+	level, tilt := int(math.Floor(float64(sp.height))), int(sp.tilt*36/math.Pi)
+	if sp.enabled == sp.meshed && (!sp.enabled || (level == sp.meshedLevel && tilt == sp.meshedTilt)) {
+		return false
+	}
+	sp.meshed, sp.meshedLevel, sp.meshedTilt = sp.enabled, level, tilt
+	return true
+}
+
+// modelLOD are the levels of detail of the model. They are meshed again with
+// the section plane.
+var modelLOD = rendering.LODOptions{
+	Levels: 3,
+	Mode:   rendering.MajorityColor,
+	Skirts: true,
 }
 
 type ChunkObj struct {
 	size     mgl.Vec3
 	pos      mgl.Vec3
+	chunk    rendering.Chunk
 	renderer rendering.Renderer
+	// pending is the mesh of the current section which replaces the renderer
+	// once it is uploaded.
+	pending *rendering.AsyncMesh
+}
+
+func (co *ChunkObj) remesh(e *Engine) {
+	if co.pending != nil {
+		co.pending.Close()
+	}
+	opt := rendering.Options{Backend: e.Backend}
+	if section.enabled {
+		opt.ClipPlanes = []rendering.ClipPlane{section.plane(co.size.Mul(0.5))}
+	}
+	co.pending = rendering.NewAsyncMesh(e.Mesher, rendering.MeshRequest{
+		Chunk:    co.chunk,
+		Options:  opt,
+		Priority: e.meshPriority(co),
+		Tag:      co,
+		LOD:      &modelLOD,
+	})
+}
+
+func (co *ChunkObj) swapPending() {
+	if co.pending == nil || !co.pending.Ready() {
+		return
+	}
+	if rc, ok := co.renderer.(rendering.RenderCloser); ok {
+		rc.Close()
+	}
+	co.renderer = co.pending
+	co.pending = nil
 }
 
 func (co *ChunkObj) Position() mgl.Vec3 {
@@ -51,8 +156,11 @@ func (co *ChunkObj) Size() mgl.Vec3 {
 func (co *ChunkObj) Renderer() rendering.Renderer {
 	return co.renderer
 }
+func (co *ChunkObj) Chunk() rendering.Chunk {
+	return co.chunk
+}
 
-func loadModelFile() (rendering.Object, error) {
+func loadModelFile(b rendering.Backend) (*ChunkObj, error) {
 	fn := "chr_knight.vox"
 	if len(os.Args) > 1 {
 		fn = os.Args[1]
@@ -66,11 +174,12 @@ func loadModelFile() (rendering.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	ro := rendering.NewRenderedChunk(vf, rendering.NONE)
+	ro := rendering.NewLODMesh(vf, rendering.Options{Backend: b}, modelLOD)
 
 	return &ChunkObj{
 		size:     vf.Size().Vec3(),
 		pos:      mgl.Vec3{0, 0, 0},
+		chunk:    vf,
 		renderer: ro,
 	}, nil
 }
@@ -0,0 +1,203 @@
+package mgl
+
+// AABB is an axis aligned box containing all points from Min to Max.
+type AABB struct {
+	Min, Max Vec3
+}
+
+// AABBFromSize returns the box at pos with the given size.
+func AABBFromSize(pos, size Vec3) AABB {
+	return AABB{pos, pos.Add(size)}
+}
+
+func (b AABB) Size() Vec3 {
+	return b.Max.Sub(b.Min)
+}
+func (b AABB) Center() Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Empty checks if the box contains no points.
+func (b AABB) Empty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+// Union returns the smallest box containing both boxes.
+func (b1 AABB) Union(b2 AABB) AABB {
+	if b1.Empty() {
+		return b2
+	} else if b2.Empty() {
+		return b1
+	}
+	return AABB{minVec3(b1.Min, b2.Min), maxVec3(b1.Max, b2.Max)}
+}
+
+// Intersection returns the box contained in both boxes. The result is empty
+// if the boxes do not overlap.
+func (b1 AABB) Intersection(b2 AABB) AABB {
+	return AABB{maxVec3(b1.Min, b2.Min), minVec3(b1.Max, b2.Max)}
+}
+
+// Intersects checks if the boxes overlap or touch.
+func (b1 AABB) Intersects(b2 AABB) bool {
+	return !b1.Intersection(b2).Empty()
+}
+
+// Contains checks if the point is within the box including its border.
+func (b AABB) Contains(p Vec3) bool {
+	for i := range p {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAABB checks if the other box is completely within the box.
+func (b1 AABB) ContainsAABB(b2 AABB) bool {
+	return b1.Contains(b2.Min) && b1.Contains(b2.Max)
+}
+
+// Expand grows the box by d on every side.
+func (b AABB) Expand(d Vec3) AABB {
+	return AABB{b.Min.Sub(d), b.Max.Add(d)}
+}
+
+// Extend returns the smallest box containing the box and the point.
+func (b AABB) Extend(p Vec3) AABB {
+	return b.Union(AABB{p, p})
+}
+
+// Transform returns the box containing the transformed corners of the box.
+func (b AABB) Transform(m Mat4) AABB {
+	// each axis of the result is the sum of the smallest and largest
+	// contributions of the columns of m.
+	res := AABB{Vec3{m[12], m[13], m[14]}, Vec3{m[12], m[13], m[14]}}
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			e := m[col*4+row] * b.Min[col]
+			f := m[col*4+row] * b.Max[col]
+			if e > f {
+				e, f = f, e
+			}
+			res.Min[row] += e
+			res.Max[row] += f
+		}
+	}
+	return res
+}
+
+// AABBI is an axis aligned box of integer coordinates like voxel positions.
+// It contains the positions from Min up to but excluding Max.
+type AABBI struct {
+	Min, Max Vec3I
+}
+
+// AABBIFromSize returns the box at pos with the given size.
+func AABBIFromSize(pos, size Vec3I) AABBI {
+	return AABBI{pos, pos.Add(size)}
+}
+
+func (b AABBI) Size() Vec3I {
+	return b.Max.Sub(b.Min)
+}
+
+// Empty checks if the box contains no positions.
+func (b AABBI) Empty() bool {
+	return b.Min[0] >= b.Max[0] || b.Min[1] >= b.Max[1] || b.Min[2] >= b.Max[2]
+}
+
+// Union returns the smallest box containing both boxes.
+func (b1 AABBI) Union(b2 AABBI) AABBI {
+	if b1.Empty() {
+		return b2
+	} else if b2.Empty() {
+		return b1
+	}
+	return AABBI{minVec3I(b1.Min, b2.Min), maxVec3I(b1.Max, b2.Max)}
+}
+
+// Intersection returns the positions contained in both boxes.
+func (b1 AABBI) Intersection(b2 AABBI) AABBI {
+	return AABBI{maxVec3I(b1.Min, b2.Min), minVec3I(b1.Max, b2.Max)}
+}
+
+// Intersects checks if the boxes share at least one position.
+func (b1 AABBI) Intersects(b2 AABBI) bool {
+	return !b1.Intersection(b2).Empty()
+}
+
+// Contains checks if the position is within the box.
+func (b AABBI) Contains(p Vec3I) bool {
+	for i := range p {
+		if p[i] < b.Min[i] || p[i] >= b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAABB checks if the other box is completely within the box.
+func (b1 AABBI) ContainsAABB(b2 AABBI) bool {
+	if b2.Empty() {
+		return true
+	}
+	return b1.Intersection(b2) == b2
+}
+
+// Expand grows the box by n on every side.
+func (b AABBI) Expand(n int) AABBI {
+	d := Vec3I{n, n, n}
+	return AABBI{b.Min.Sub(d), b.Max.Add(d)}
+}
+
+// Extend returns the smallest box containing the box and the position.
+func (b AABBI) Extend(p Vec3I) AABBI {
+	return b.Union(AABBI{p, p.Add(Vec3I{1, 1, 1})})
+}
+
+// AABB returns the space covered by the positions.
+func (b AABBI) AABB() AABB {
+	return AABB{b.Min.Vec3(), b.Max.Vec3()}
+}
+
+// Transform returns the box containing the transformed space of the box.
+func (b AABBI) Transform(m Mat4) AABB {
+	return b.AABB().Transform(m)
+}
+
+func minVec3(v1, v2 Vec3) Vec3 {
+	for i := range v1 {
+		if v2[i] < v1[i] {
+			v1[i] = v2[i]
+		}
+	}
+	return v1
+}
+
+func maxVec3(v1, v2 Vec3) Vec3 {
+	for i := range v1 {
+		if v2[i] > v1[i] {
+			v1[i] = v2[i]
+		}
+	}
+	return v1
+}
+
+func minVec3I(v1, v2 Vec3I) Vec3I {
+	for i := range v1 {
+		if v2[i] < v1[i] {
+			v1[i] = v2[i]
+		}
+	}
+	return v1
+}
+
+func maxVec3I(v1, v2 Vec3I) Vec3I {
+	for i := range v1 {
+		if v2[i] > v1[i] {
+			v1[i] = v2[i]
+		}
+	}
+	return v1
+}
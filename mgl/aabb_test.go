@@ -0,0 +1,114 @@
+package mgl
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_AABBUnionIntersection(t *testing.T) {
+	b1 := AABB{Vec3{0, 0, 0}, Vec3{2, 2, 2}}
+	b2 := AABBFromSize(Vec3{1, -1, 1}, Vec3{3, 2, 0.5})
+
+	if u := b1.Union(b2); u != (AABB{Vec3{0, -1, 0}, Vec3{4, 2, 2}}) {
+		t.Errorf("Wrong union %v", u)
+	}
+	if i := b1.Intersection(b2); i != (AABB{Vec3{1, 0, 1}, Vec3{2, 1, 1.5}}) {
+		t.Errorf("Wrong intersection %v", i)
+	}
+	if !b1.Intersects(b2) || !b2.Intersects(b1) {
+		t.Errorf("Expected the boxes to intersect")
+	}
+
+	b3 := AABB{Vec3{3, 3, 3}, Vec3{4, 4, 4}}
+	if b1.Intersects(b3) || !b1.Intersection(b3).Empty() {
+		t.Errorf("Expected separate boxes not to intersect")
+	}
+	empty := AABB{Vec3{1, 1, 1}, Vec3{0, 0, 0}}
+	if !empty.Empty() || b1.Union(empty) != b1 || empty.Union(b1) != b1 {
+		t.Errorf("Union with an empty box should not change the box")
+	}
+	if b1.Size() != (Vec3{2, 2, 2}) || b1.Center() != (Vec3{1, 1, 1}) {
+		t.Errorf("Wrong size %v or center %v", b1.Size(), b1.Center())
+	}
+}
+
+func Test_AABBContains(t *testing.T) {
+	b := AABB{Vec3{-1, 0, 0}, Vec3{1, 2, 3}}
+	for _, p := range []Vec3{{0, 1, 1}, {-1, 0, 0}, {1, 2, 3}} {
+		if !b.Contains(p) {
+			t.Errorf("Expected %v to be within %v", p, b)
+		}
+	}
+	for _, p := range []Vec3{{-1.1, 1, 1}, {0, 2.1, 1}, {0, 1, -0.1}} {
+		if b.Contains(p) {
+			t.Errorf("Expected %v not to be within %v", p, b)
+		}
+	}
+	if !b.ContainsAABB(AABB{Vec3{0, 0, 0}, Vec3{1, 1, 1}}) || b.ContainsAABB(AABB{Vec3{0, 0, 0}, Vec3{2, 1, 1}}) {
+		t.Errorf("ContainsAABB failed")
+	}
+	if e := b.Expand(Vec3{1, 0.5, 0}); e != (AABB{Vec3{-2, -0.5, 0}, Vec3{2, 2.5, 3}}) {
+		t.Errorf("Wrong expanded box %v", e)
+	}
+	if e := b.Extend(Vec3{5, 1, -2}); e != (AABB{Vec3{-1, 0, -2}, Vec3{5, 2, 3}}) {
+		t.Errorf("Wrong extended box %v", e)
+	}
+}
+
+func Test_AABBTransform(t *testing.T) {
+	b := AABB{Vec3{0, 0, 0}, Vec3{2, 1, 1}}
+	if r := b.Transform(Identity().Translate(1, 2, 3).Scale(2, 2, 2)); r != (AABB{Vec3{1, 2, 3}, Vec3{5, 4, 5}}) {
+		t.Errorf("Wrong translated box %v", r)
+	}
+
+	m := Identity().Rotate(math.Pi/4, Vec3{0, 0, 1})
+	r := b.Transform(m)
+	// the result has to contain every transformed corner
+	exp := AABB{Vec3{1, 1, 1}, Vec3{-1, -1, -1}}
+	for i := 0; i < 8; i++ {
+		c := b.Min
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				c[axis] = b.Max[axis]
+			}
+		}
+		exp = exp.Extend(m.MulVec4(c.Vec4(1)).Vec3())
+	}
+	if !vec3Near(r.Min, exp.Min) || !vec3Near(r.Max, exp.Max) {
+		t.Errorf("Expected %v but got %v", exp, r)
+	}
+}
+
+func Test_AABBI(t *testing.T) {
+	b1 := AABBIFromSize(Vec3I{0, 0, 0}, Vec3I{4, 4, 4})
+	b2 := AABBI{Vec3I{2, 3, -1}, Vec3I{6, 5, 1}}
+
+	if u := b1.Union(b2); u != (AABBI{Vec3I{0, 0, -1}, Vec3I{6, 5, 4}}) {
+		t.Errorf("Wrong union %v", u)
+	}
+	if i := b1.Intersection(b2); i != (AABBI{Vec3I{2, 3, 0}, Vec3I{4, 4, 1}}) || i.Size() != (Vec3I{2, 1, 1}) {
+		t.Errorf("Wrong intersection %v", i)
+	}
+	// touching boxes share no position
+	if b1.Intersects(AABBI{Vec3I{4, 0, 0}, Vec3I{5, 1, 1}}) {
+		t.Errorf("Expected touching boxes not to intersect")
+	}
+	if !b1.Contains(Vec3I{0, 3, 3}) || b1.Contains(Vec3I{0, 4, 3}) || b1.Contains(Vec3I{-1, 0, 0}) {
+		t.Errorf("Contains failed")
+	}
+	if !b1.ContainsAABB(AABBI{Vec3I{1, 1, 1}, Vec3I{4, 4, 4}}) || b1.ContainsAABB(b2) {
+		t.Errorf("ContainsAABB failed")
+	}
+	if e := b1.Expand(1); e != (AABBI{Vec3I{-1, -1, -1}, Vec3I{5, 5, 5}}) {
+		t.Errorf("Wrong expanded box %v", e)
+	}
+	if e := b1.Extend(Vec3I{4, 0, 0}); e != (AABBI{Vec3I{0, 0, 0}, Vec3I{5, 4, 4}}) {
+		t.Errorf("Wrong extended box %v", e)
+	}
+	if e := (AABBI{}).Extend(Vec3I{2, 2, 2}); e != (AABBI{Vec3I{2, 2, 2}, Vec3I{3, 3, 3}}) {
+		t.Errorf("Extending an empty box should contain only the position but got %v", e)
+	}
+	if f := b1.Transform(Identity().Scale(0.5, 0.5, 0.5)); f != (AABB{Vec3{0, 0, 0}, Vec3{2, 2, 2}}) {
+		t.Errorf("Wrong transformed box %v", f)
+	}
+}
@@ -0,0 +1,178 @@
+package mgl
+
+import (
+	"math"
+	"testing"
+)
+
+func mat4Near(m1, m2 Mat4, epsilon float32) bool {
+	for i := range m1 {
+		if Abs(m1[i]-m2[i]) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+var testMatrices = []Mat4{
+	Identity(),
+	Identity().Translate(1, -2, 3).Scale(2, 3, 4),
+	Identity().Rotate(0.7, Vec3{1, 2, 3}).Translate(5, 0, -1),
+	{
+		2, 0, 1, 3,
+		-1, 4, 0, 2,
+		0, 1, 5, -2,
+		3, 0, 2, 1,
+	},
+	Perspective(1, 1.5, 0.1, 100),
+}
+
+func Test_Mat4Rotate(t *testing.T) {
+	tests := []struct {
+		angle  Radian
+		axis   Vec3
+		v, exp Vec3
+	}{
+		{math.Pi / 2, Vec3{0, 0, 1}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{math.Pi / 2, Vec3{1, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{math.Pi / 2, Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{math.Pi / 2, Vec3{0, 1, 0}, Vec3{1, 0, 0}, Vec3{0, 0, -1}},
+		{2 * math.Pi / 3, Vec3{1, 1, 1}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{2 * math.Pi / 3, Vec3{1, 1, 1}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+	}
+	for _, test := range tests {
+		m := Identity().Rotate(test.angle, test.axis)
+		if r := m.MulVec4(test.v.Vec4(1)).Vec3(); !vec3Near(r, test.exp) {
+			t.Errorf("Rotating %v by %v around %v gave %v", test.v, test.angle, test.axis, r)
+		}
+	}
+
+	axis := Vec3{-1, 3, 0.5}
+	for _, angle := range []Radian{0.3, -1.1, 2.5} {
+		if m := Identity().Rotate(angle, axis); !mat4Near(m, QuatRotate(angle, axis).Mat4(), 1e-6) {
+			t.Errorf("Rotate by %v does not match the quaternion:\n%v", angle, m)
+		}
+	}
+	if d := Identity().Rotate(0.3, axis).Det(); !FloatEqualThreshold(d, 1, 1e-5) {
+		t.Errorf("Rotation has determinant %f", d)
+	}
+}
+
+func Test_Mat4Transpose(t *testing.T) {
+	m := testMatrices[3]
+	tr := m.Transpose()
+	for i := 0; i < 4; i++ {
+		if m.Row(i) != tr.Col(i) {
+			t.Errorf("Row %d should be column %d of the transposed matrix", i, i)
+		}
+	}
+	if tr.Transpose() != m {
+		t.Errorf("Transposing twice should return the matrix")
+	}
+}
+
+func Test_Mat4Det(t *testing.T) {
+	tests := []struct {
+		m   Mat4
+		det float32
+	}{
+		{Identity(), 1},
+		{Identity().Scale(2, 3, 4), 24},
+		{Identity().Translate(7, 8, 9), 1},
+		{testMatrices[3], -125},
+		{Mat4{1, 2, 3, 4, 2, 4, 6, 8, 0, 1, 0, 1, 5, 0, 0, 1}, 0},
+	}
+	for _, test := range tests {
+		if d := test.m.Det(); !FloatEqualThreshold(d, test.det, 1e-5) {
+			t.Errorf("Expected determinant %f but got %f for\n%v", test.det, d, test.m)
+		}
+	}
+}
+
+func Test_Mat4Inverse(t *testing.T) {
+	for _, m := range testMatrices {
+		inv := m.Inverse()
+		if !mat4Near(m.MulMat4(inv), Identity(), 1e-4) || !mat4Near(inv.MulMat4(m), Identity(), 1e-4) {
+			t.Errorf("Inverse failed for\n%v", m)
+		}
+	}
+	if inv := (Mat4{1, 2, 3, 4, 2, 4, 6, 8, 0, 1, 0, 1, 5, 0, 0, 1}).Inverse(); inv != (Mat4{}) {
+		t.Errorf("Expected the zero matrix for a singular matrix but got\n%v", inv)
+	}
+}
+
+func Test_LookAt(t *testing.T) {
+	eye := Vec3{1, 2, 3}
+	m := LookAt(eye, Vec3{1, 2, -7}, Vec3{0, 1, 0})
+	if !mat4Near(m, Identity().Translate(-1, -2, -3), 1e-6) {
+		t.Errorf("Looking along -Z should only translate but got\n%v", m)
+	}
+
+	m = LookAt(Vec3{5, 0, 0}, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+	tests := []struct{ v, exp Vec3 }{
+		{Vec3{5, 0, 0}, Vec3{0, 0, 0}},
+		{Vec3{0, 0, 0}, Vec3{0, 0, -5}},
+		{Vec3{5, 1, 0}, Vec3{0, 1, 0}},
+		{Vec3{5, 0, -1}, Vec3{1, 0, 0}},
+	}
+	for _, test := range tests {
+		if r := m.MulVec4(test.v.Vec4(1)).Vec3(); !vec3Near(r, test.exp) {
+			t.Errorf("Expected %v in view space at %v but got %v", test.v, test.exp, r)
+		}
+	}
+}
+
+func Test_Perspective(t *testing.T) {
+	p := Perspective(Degree(90).ToRadian(), 2, 1, 10)
+	tests := []struct{ v, exp Vec3 }{
+		{Vec3{0, 0, -1}, Vec3{0, 0, -1}},
+		{Vec3{0, 0, -10}, Vec3{0, 0, 1}},
+		{Vec3{2, 1, -1}, Vec3{1, 1, -1}},
+		{Vec3{-20, -10, -10}, Vec3{-1, -1, 1}},
+	}
+	for _, test := range tests {
+		c := p.MulVec4(test.v.Vec4(1))
+		if r := c.Vec3().Mul(1 / c.W()); !vec3Near(r, test.exp) {
+			t.Errorf("Expected %v at %v in NDC but got %v", test.v, test.exp, r)
+		}
+	}
+}
+
+func Test_Ortho(t *testing.T) {
+	p := Ortho(-4, 2, 0, 3, 1, 11)
+	tests := []struct{ v, exp Vec3 }{
+		{Vec3{-4, 0, -1}, Vec3{-1, -1, -1}},
+		{Vec3{2, 3, -11}, Vec3{1, 1, 1}},
+		{Vec3{-1, 1.5, -6}, Vec3{0, 0, 0}},
+	}
+	for _, test := range tests {
+		if r := p.MulVec4(test.v.Vec4(1)); !vec3Near(r.Vec3(), test.exp) || r.W() != 1 {
+			t.Errorf("Expected %v at %v in NDC but got %v", test.v, test.exp, r)
+		}
+	}
+}
+
+func Test_ProjectUnproject(t *testing.T) {
+	view := LookAt(Vec3{3, 4, 10}, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+	proj := Perspective(Degree(45).ToRadian(), 4.0/3.0, 0.1, 100)
+
+	win := Project(Vec3{0, 0, 0}, view, proj, 10, 20, 800, 600)
+	if !FloatEqualThreshold(win[0], 410, 1e-4) || !FloatEqualThreshold(win[1], 320, 1e-4) {
+		t.Errorf("Expected the target at the center of the viewport but got %v", win)
+	}
+	if win[2] <= 0 || win[2] >= 1 {
+		t.Errorf("Expected a depth between 0 and 1 but got %f", win[2])
+	}
+
+	for _, obj := range []Vec3{{0, 0, 0}, {1, -2, 0.5}, {-3, 1, 2}} {
+		win := Project(obj, view, proj, 10, 20, 800, 600)
+		r, err := Unproject(win, view, proj, 10, 20, 800, 600)
+		if err != nil || Abs(r[0]-obj[0]) > 1e-3 || Abs(r[1]-obj[1]) > 1e-3 || Abs(r[2]-obj[2]) > 1e-3 {
+			t.Errorf("Expected %v after unprojecting but got %v (%v)", obj, r, err)
+		}
+	}
+
+	if _, err := Unproject(Vec3{1, 1, 0.5}, Mat4{}, proj, 0, 0, 800, 600); err == nil {
+		t.Errorf("Expected an error for a singular matrix")
+	}
+}
@@ -0,0 +1,76 @@
+package mgl
+
+import (
+	"errors"
+	"math"
+)
+
+// LookAt returns a view matrix like gluLookAt for a camera at eye looking at
+// center.
+func LookAt(eye, center, up Vec3) Mat4 {
+	f := center.Sub(eye).Normalize()
+	s := f.Cross(up.Normalize()).Normalize()
+	u := s.Cross(f)
+
+	return Mat4{
+		s[0], u[0], -f[0], 0,
+		s[1], u[1], -f[1], 0,
+		s[2], u[2], -f[2], 0,
+		-s.Dot(eye), -u.Dot(eye), f.Dot(eye), 1,
+	}
+}
+
+// Perspective returns a projection matrix like gluPerspective with the
+// vertical field of view fovy.
+func Perspective(fovy Radian, aspect, near, far float32) Mat4 {
+	f := float32(1 / math.Tan(float64(fovy)/2))
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), -1,
+		0, 0, 2 * far * near / (near - far), 0,
+	}
+}
+
+// Ortho returns an orthographic projection matrix like glOrtho.
+func Ortho(left, right, bottom, top, near, far float32) Mat4 {
+	return Mat4{
+		2 / (right - left), 0, 0, 0,
+		0, 2 / (top - bottom), 0, 0,
+		0, 0, -2 / (far - near), 0,
+		-(right + left) / (right - left), -(top + bottom) / (top - bottom), -(far + near) / (far - near), 1,
+	}
+}
+
+// Project maps object coordinates to window coordinates like gluProject.
+// The z coordinate of the result is the depth in the range 0 to 1.
+func Project(obj Vec3, modelView, projection Mat4, x, y, width, height int) Vec3 {
+	clip := projection.MulMat4(modelView).MulVec4(obj.Vec4(1))
+	ndc := clip.Vec3().Mul(1 / clip.W())
+	return Vec3{
+		float32(x) + float32(width)*(ndc[0]+1)/2,
+		float32(y) + float32(height)*(ndc[1]+1)/2,
+		(ndc[2] + 1) / 2,
+	}
+}
+
+var errNotInvertible = errors.New("mgl: matrix is not invertible")
+
+// Unproject maps window coordinates to object coordinates like gluUnProject.
+func Unproject(win Vec3, modelView, projection Mat4, x, y, width, height int) (Vec3, error) {
+	inv := projection.MulMat4(modelView).Inverse()
+	if inv == (Mat4{}) {
+		return Vec3{}, errNotInvertible
+	}
+	ndc := Vec4{
+		2*(win[0]-float32(x))/float32(width) - 1,
+		2*(win[1]-float32(y))/float32(height) - 1,
+		2*win[2] - 1,
+		1,
+	}
+	obj := inv.MulVec4(ndc)
+	if obj.W() == 0 {
+		return Vec3{}, errNotInvertible
+	}
+	return obj.Vec3().Mul(1 / obj.W()), nil
+}
@@ -0,0 +1,172 @@
+package mgl
+
+import "math"
+
+// Quat is a quaternion with the scalar part W and the vector part V. Unit
+// quaternions represent rotations.
+type Quat struct {
+	W float32
+	V Vec3
+}
+
+// QuatIdent returns the quaternion which does not rotate.
+func QuatIdent() Quat {
+	return Quat{1, Vec3{0, 0, 0}}
+}
+
+// QuatRotate returns the rotation by angle around the axis.
+func QuatRotate(angle Radian, axis Vec3) Quat {
+	s, c := math.Sincos(float64(angle) / 2)
+	return Quat{float32(c), axis.Normalize().Mul(float32(s))}
+}
+
+// EulerToQuat returns the rotation by roll around Z followed by pitch around
+// X and yaw around Y.
+func EulerToQuat(yaw, pitch, roll Radian) Quat {
+	return QuatRotate(yaw, Vec3{0, 1, 0}).
+		Mul(QuatRotate(pitch, Vec3{1, 0, 0})).
+		Mul(QuatRotate(roll, Vec3{0, 0, 1}))
+}
+
+func (q Quat) X() float32 {
+	return q.V[0]
+}
+func (q Quat) Y() float32 {
+	return q.V[1]
+}
+func (q Quat) Z() float32 {
+	return q.V[2]
+}
+
+func (q1 Quat) Add(q2 Quat) Quat {
+	return Quat{q1.W + q2.W, q1.V.Add(q2.V)}
+}
+func (q1 Quat) Sub(q2 Quat) Quat {
+	return Quat{q1.W - q2.W, q1.V.Sub(q2.V)}
+}
+
+// Mul returns the product of the quaternions. The resulting rotation applies
+// q2 first and q1 afterwards.
+func (q1 Quat) Mul(q2 Quat) Quat {
+	return Quat{
+		q1.W*q2.W - q1.V.Dot(q2.V),
+		q2.V.Mul(q1.W).Add(q1.V.Mul(q2.W)).Add(q1.V.Cross(q2.V)),
+	}
+}
+func (q1 Quat) Scale(c float32) Quat {
+	return Quat{q1.W * c, q1.V.Mul(c)}
+}
+func (q1 Quat) Dot(q2 Quat) float32 {
+	return q1.W*q2.W + q1.V.Dot(q2.V)
+}
+func (q1 Quat) Len() float32 {
+	return float32(math.Sqrt(float64(q1.Dot(q1))))
+}
+
+// Normalize returns the unit quaternion of q. The zero quaternion results in
+// the identity.
+func (q1 Quat) Normalize() Quat {
+	l := q1.Len()
+	if l == 0 {
+		return QuatIdent()
+	}
+	return q1.Scale(1 / l)
+}
+func (q1 Quat) Conjugate() Quat {
+	return Quat{q1.W, q1.V.Mul(-1)}
+}
+func (q1 Quat) Inverse() Quat {
+	return q1.Conjugate().Scale(1 / q1.Dot(q1))
+}
+
+// Rotate rotates the vector by the unit quaternion.
+func (q1 Quat) Rotate(v Vec3) Vec3 {
+	t := q1.V.Cross(v).Mul(2)
+	return v.Add(t.Mul(q1.W)).Add(q1.V.Cross(t))
+}
+
+// AxisAngle returns the axis and the angle of the rotation.
+func (q1 Quat) AxisAngle() (Radian, Vec3) {
+	q1 = q1.Normalize()
+	if q1.W < 0 {
+		q1 = q1.Scale(-1)
+	}
+	s := q1.V.Len()
+	if s < 1e-6 {
+		return 0, Vec3{1, 0, 0}
+	}
+	return Radian(2 * math.Atan2(float64(s), float64(q1.W))), q1.V.Mul(1 / s)
+}
+
+// Euler returns the angles of the rotation in the order used by EulerToQuat.
+func (q1 Quat) Euler() (yaw, pitch, roll Radian) {
+	m := q1.Normalize().Mat4()
+	sinPitch := -m[9]
+	if sinPitch > 1 {
+		sinPitch = 1
+	} else if sinPitch < -1 {
+		sinPitch = -1
+	}
+	pitch = Radian(math.Asin(float64(sinPitch)))
+	if Abs(sinPitch) < 0.9999999 {
+		yaw = Radian(math.Atan2(float64(m[8]), float64(m[10])))
+		roll = Radian(math.Atan2(float64(m[1]), float64(m[5])))
+	} else {
+		// gimbal lock: only the sum of yaw and roll is defined.
+		yaw = Radian(math.Atan2(float64(-m[2]), float64(m[0])))
+	}
+	return yaw, pitch, roll
+}
+
+// Mat4 returns the rotation matrix of the unit quaternion.
+func (q1 Quat) Mat4() Mat4 {
+	w, x, y, z := q1.W, q1.V[0], q1.V[1], q1.V[2]
+	return Mat4{
+		1 - 2*(y*y+z*z), 2 * (x*y + w*z), 2 * (x*z - w*y), 0,
+		2 * (x*y - w*z), 1 - 2*(x*x+z*z), 2 * (y*z + w*x), 0,
+		2 * (x*z + w*y), 2 * (y*z - w*x), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Mat4ToQuat returns the rotation of a matrix without scale.
+func Mat4ToQuat(m Mat4) Quat {
+	m00, m01, m02 := m[0], m[4], m[8]
+	m10, m11, m12 := m[1], m[5], m[9]
+	m20, m21, m22 := m[2], m[6], m[10]
+
+	if tr := m00 + m11 + m22; tr > 0 {
+		s := 0.5 / float32(math.Sqrt(float64(tr+1)))
+		return Quat{0.25 / s, Vec3{(m21 - m12) * s, (m02 - m20) * s, (m10 - m01) * s}}
+	} else if m00 > m11 && m00 > m22 {
+		s := 2 * float32(math.Sqrt(float64(1+m00-m11-m22)))
+		return Quat{(m21 - m12) / s, Vec3{0.25 * s, (m01 + m10) / s, (m02 + m20) / s}}
+	} else if m11 > m22 {
+		s := 2 * float32(math.Sqrt(float64(1+m11-m00-m22)))
+		return Quat{(m02 - m20) / s, Vec3{(m01 + m10) / s, 0.25 * s, (m12 + m21) / s}}
+	}
+	s := 2 * float32(math.Sqrt(float64(1+m22-m00-m11)))
+	return Quat{(m10 - m01) / s, Vec3{(m02 + m20) / s, (m12 + m21) / s, 0.25 * s}}
+}
+
+// Slerp interpolates along the shortest arc between the unit quaternions.
+func Slerp(q1, q2 Quat, amount float32) Quat {
+	dot := q1.Dot(q2)
+	if dot < 0 {
+		q2, dot = q2.Scale(-1), -dot
+	}
+	if dot > 0.9995 {
+		// the quaternions are almost equal, interpolate linearly
+		return q1.Add(q2.Sub(q1).Scale(amount)).Normalize()
+	}
+	theta := math.Acos(float64(dot))
+	sin := math.Sin(theta)
+	s1 := float32(math.Sin((1-float64(amount))*theta) / sin)
+	s2 := float32(math.Sin(float64(amount)*theta) / sin)
+	return q1.Scale(s1).Add(q2.Scale(s2))
+}
+
+// ApproxEqual checks if the quaternions describe the same rotation.
+func (q1 Quat) ApproxEqual(q2 Quat, epsilon float32) bool {
+	return Abs(Abs(q1.Normalize().Dot(q2.Normalize()))-1) <= epsilon
+}
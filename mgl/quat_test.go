@@ -0,0 +1,174 @@
+package mgl
+
+import (
+	"math"
+	"testing"
+)
+
+func vec3Near(v1, v2 Vec3) bool {
+	for i := range v1 {
+		if Abs(v1[i]-v2[i]) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_QuatMul(t *testing.T) {
+	i := Quat{0, Vec3{1, 0, 0}}
+	j := Quat{0, Vec3{0, 1, 0}}
+	k := Quat{0, Vec3{0, 0, 1}}
+
+	if i.Mul(j) != k || j.Mul(k) != i || k.Mul(i) != j {
+		t.Errorf("Mul does not follow ij = k, jk = i, ki = j")
+	}
+	if j.Mul(i) != k.Scale(-1) {
+		t.Errorf("Mul should not be commutative")
+	}
+	if i.Mul(i) != (Quat{-1, Vec3{}}) {
+		t.Errorf("i*i should be -1")
+	}
+	q := Quat{1, Vec3{2, 3, 4}}
+	if q.Mul(QuatIdent()) != q || QuatIdent().Mul(q) != q {
+		t.Errorf("Identity changed the quaternion")
+	}
+}
+
+func Test_QuatNormalize(t *testing.T) {
+	q := Quat{1, Vec3{2, 3, 4}}.Normalize()
+	if !FloatEqualThreshold(q.Len(), 1, 1e-6) {
+		t.Errorf("Normalized quaternion has length %f", q.Len())
+	}
+	if (Quat{}).Normalize() != QuatIdent() {
+		t.Errorf("Zero quaternion should normalize to identity")
+	}
+	inv := Quat{1, Vec3{2, 3, 4}}.Inverse()
+	if p := inv.Mul(Quat{1, Vec3{2, 3, 4}}); !p.ApproxEqual(QuatIdent(), 1e-6) || !FloatEqualThreshold(p.W, 1, 1e-6) {
+		t.Errorf("q^-1 * q should be identity but is %v", p)
+	}
+}
+
+func Test_QuatRotate(t *testing.T) {
+	tests := []struct {
+		angle  Radian
+		axis   Vec3
+		v, exp Vec3
+	}{
+		{math.Pi / 2, Vec3{0, 0, 1}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{math.Pi / 2, Vec3{1, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{math.Pi / 2, Vec3{0, 1, 0}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{math.Pi, Vec3{0, 2, 0}, Vec3{1, 2, 3}, Vec3{-1, 2, -3}},
+		{2 * math.Pi / 3, Vec3{1, 1, 1}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+	}
+	for _, test := range tests {
+		q := QuatRotate(test.angle, test.axis)
+		if r := q.Rotate(test.v); !vec3Near(r, test.exp) {
+			t.Errorf("Rotating %v by %v around %v gave %v", test.v, test.angle, test.axis, r)
+		}
+		if r := q.Mat4().MulVec4(test.v.Vec4(1)).Vec3(); !vec3Near(r, test.exp) {
+			t.Errorf("Rotation matrix of %v around %v gave %v", test.angle, test.axis, r)
+		}
+		if r := q.Mul(Quat{0, test.v}).Mul(q.Conjugate()).V; !vec3Near(r, test.exp) {
+			t.Errorf("q*v*q' of %v around %v gave %v", test.angle, test.axis, r)
+		}
+	}
+}
+
+func Test_QuatCompose(t *testing.T) {
+	q1 := QuatRotate(0.3, Vec3{1, 2, 3})
+	q2 := QuatRotate(-1.2, Vec3{0, 1, -1})
+	v := Vec3{0.5, -2, 1}
+	if !vec3Near(q1.Mul(q2).Rotate(v), q1.Rotate(q2.Rotate(v))) {
+		t.Errorf("q1*q2 should rotate by q2 first and q1 afterwards")
+	}
+	m := q1.Mat4().MulMat4(q2.Mat4())
+	if !vec3Near(m.MulVec4(v.Vec4(1)).Vec3(), q1.Mul(q2).Rotate(v)) {
+		t.Errorf("Matrix product does not match quaternion product")
+	}
+}
+
+func Test_QuatAxisAngle(t *testing.T) {
+	axis := Vec3{1, -2, 2}.Normalize()
+	angle, a := QuatRotate(1.1, axis).AxisAngle()
+	if !FloatEqualThreshold(float32(angle), 1.1, 1e-5) || !vec3Near(a, axis) {
+		t.Errorf("Expected 1.1 around %v but got %v around %v", axis, angle, a)
+	}
+	// negative angles are returned as the positive angle around the flipped axis
+	angle, a = QuatRotate(-0.5, axis).AxisAngle()
+	if !FloatEqualThreshold(float32(angle), 0.5, 1e-5) || !vec3Near(a, axis.Mul(-1)) {
+		t.Errorf("Expected 0.5 around %v but got %v around %v", axis.Mul(-1), angle, a)
+	}
+	if angle, _ := QuatIdent().AxisAngle(); angle != 0 {
+		t.Errorf("Identity should have no angle but has %v", angle)
+	}
+}
+
+func Test_QuatEuler(t *testing.T) {
+	tests := [][3]Radian{
+		{0, 0, 0},
+		{0.5, 0, 0},
+		{0, 0.5, 0},
+		{0, 0, 0.5},
+		{1.2, -0.4, 2.5},
+		{-2.8, 1.3, -0.1},
+	}
+	for _, test := range tests {
+		q := EulerToQuat(test[0], test[1], test[2])
+		yaw, pitch, roll := q.Euler()
+		if !FloatEqualThreshold(float32(yaw), float32(test[0]), 1e-4) ||
+			!FloatEqualThreshold(float32(pitch), float32(test[1]), 1e-4) ||
+			!FloatEqualThreshold(float32(roll), float32(test[2]), 1e-4) {
+			t.Errorf("Expected %v but got %v %v %v", test, yaw, pitch, roll)
+		}
+	}
+	// yaw turns the forward vector to the right hand side
+	if r := EulerToQuat(math.Pi/2, 0, 0).Rotate(Vec3{0, 0, 1}); !vec3Near(r, Vec3{1, 0, 0}) {
+		t.Errorf("Yaw rotated +Z to %v", r)
+	}
+	// in the gimbal lock the rotation is still preserved
+	q := EulerToQuat(0.3, math.Pi/2, 0.2)
+	if yaw, pitch, roll := q.Euler(); !EulerToQuat(yaw, pitch, roll).ApproxEqual(q, 1e-5) {
+		t.Errorf("Gimbal lock changed the rotation")
+	}
+}
+
+func Test_QuatMat4(t *testing.T) {
+	tests := []Quat{
+		QuatIdent(),
+		QuatRotate(0.7, Vec3{1, 2, 3}),
+		QuatRotate(math.Pi, Vec3{1, 0, 0}),
+		QuatRotate(math.Pi, Vec3{0, 1, 0}),
+		QuatRotate(math.Pi, Vec3{0, 0, 1}),
+		QuatRotate(3, Vec3{-1, 0.2, 0.1}),
+	}
+	for _, q := range tests {
+		if r := Mat4ToQuat(q.Mat4()); !r.ApproxEqual(q, 1e-6) {
+			t.Errorf("Expected %v but got %v", q, r)
+		}
+	}
+	m := Identity().Translate(1, 2, 3).MulMat4(QuatRotate(1, Vec3{0, 1, 0}).Mat4())
+	if r := Mat4ToQuat(m); !r.ApproxEqual(QuatRotate(1, Vec3{0, 1, 0}), 1e-6) {
+		t.Errorf("Translation should be ignored but got %v", r)
+	}
+}
+
+func Test_QuatSlerp(t *testing.T) {
+	axis := Vec3{0, 1, 0}
+	q1, q2 := QuatRotate(0.2, axis), QuatRotate(1.4, axis)
+	if r := Slerp(q1, q2, 0); !r.ApproxEqual(q1, 1e-6) {
+		t.Errorf("Slerp at 0 should return q1 but got %v", r)
+	}
+	if r := Slerp(q1, q2, 1); !r.ApproxEqual(q2, 1e-6) {
+		t.Errorf("Slerp at 1 should return q2 but got %v", r)
+	}
+	if r := Slerp(q1, q2, 0.25); !r.ApproxEqual(QuatRotate(0.5, axis), 1e-6) {
+		t.Errorf("Slerp at 0.25 should rotate by 0.5 but got %v", r)
+	}
+	// the shortest path is taken even if the signs differ
+	if r := Slerp(q1, q2.Scale(-1), 0.5); !r.ApproxEqual(QuatRotate(0.8, axis), 1e-6) {
+		t.Errorf("Slerp did not take the shortest path: %v", r)
+	}
+	if r := Slerp(q1, q1, 0.5); !FloatEqualThreshold(r.Len(), 1, 1e-6) || !r.ApproxEqual(q1, 1e-6) {
+		t.Errorf("Slerp of equal quaternions gave %v", r)
+	}
+}
@@ -0,0 +1,93 @@
+package mgl
+
+import "math"
+
+// Ray is a half line starting at Origin. Distances along the ray are
+// measured in multiples of Dir.
+type Ray struct {
+	Origin, Dir Vec3
+}
+
+// At returns the point at the distance t along the ray.
+func (r Ray) At(t float32) Vec3 {
+	return r.Origin.Add(r.Dir.Mul(t))
+}
+
+// IntersectAABB returns the distance where the ray enters the box and the
+// normal of the face it enters. A ray starting within the box hits at
+// distance 0 with a zero normal.
+func (r Ray) IntersectAABB(b AABB) (t float32, normal Vec3, ok bool) {
+	tNear, tFar := float32(0), float32(math.Inf(1))
+	axis := -1
+	for i := 0; i < 3; i++ {
+		if r.Dir[i] == 0 {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return 0, Vec3{}, false
+			}
+			continue
+		}
+		t1 := (b.Min[i] - r.Origin[i]) / r.Dir[i]
+		t2 := (b.Max[i] - r.Origin[i]) / r.Dir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tNear {
+			tNear, axis = t1, i
+		}
+		if t2 < tFar {
+			tFar = t2
+		}
+		if tNear > tFar {
+			return 0, Vec3{}, false
+		}
+	}
+	if axis >= 0 {
+		if r.Dir[axis] > 0 {
+			normal[axis] = -1
+		} else {
+			normal[axis] = 1
+		}
+	}
+	return tNear, normal, true
+}
+
+// IntersectPlane returns the distance where the ray hits the plane of all
+// points p with n·p = d. The returned normal faces the origin of the ray.
+func (r Ray) IntersectPlane(n Vec3, d float32) (t float32, normal Vec3, ok bool) {
+	denom := n.Dot(r.Dir)
+	if denom == 0 {
+		return 0, Vec3{}, false
+	}
+	t = (d - n.Dot(r.Origin)) / denom
+	if t < 0 {
+		return 0, Vec3{}, false
+	}
+	normal = n.Normalize()
+	if denom > 0 {
+		normal = normal.Mul(-1)
+	}
+	return t, normal, true
+}
+
+// IntersectSphere returns the distance of the first point of the sphere hit
+// by the ray and the outward normal of the sphere at that point. A ray
+// starting within the sphere hits the sphere where it leaves it.
+func (r Ray) IntersectSphere(center Vec3, radius float32) (t float32, normal Vec3, ok bool) {
+	oc := r.Origin.Sub(center)
+	a := r.Dir.Dot(r.Dir)
+	b := oc.Dot(r.Dir)
+	c := oc.Dot(oc) - radius*radius
+	disc := b*b - a*c
+	if a == 0 || disc < 0 {
+		return 0, Vec3{}, false
+	}
+	sq := float32(math.Sqrt(float64(disc)))
+	t = (-b - sq) / a
+	if t < 0 {
+		t = (-b + sq) / a
+		if t < 0 {
+			return 0, Vec3{}, false
+		}
+	}
+	return t, r.At(t).Sub(center).Mul(1 / radius), true
+}
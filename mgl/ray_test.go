@@ -0,0 +1,68 @@
+package mgl
+
+import (
+	"testing"
+)
+
+func Test_RayAABB(t *testing.T) {
+	b := AABB{Vec3{0, 0, 0}, Vec3{2, 2, 2}}
+	tests := []struct {
+		ray    Ray
+		ok     bool
+		t      float32
+		normal Vec3
+	}{
+		{Ray{Vec3{-3, 1, 1}, Vec3{1, 0, 0}}, true, 3, Vec3{-1, 0, 0}},
+		{Ray{Vec3{1, 5, 1}, Vec3{0, -2, 0}}, true, 1.5, Vec3{0, 1, 0}},
+		{Ray{Vec3{-1, -1, 1}, Vec3{1, 1, 0}}, true, 1, Vec3{-1, 0, 0}},
+		{Ray{Vec3{1, 1, 1}, Vec3{0, 0, 1}}, true, 0, Vec3{}},
+		{Ray{Vec3{-3, 1, 1}, Vec3{-1, 0, 0}}, false, 0, Vec3{}},
+		{Ray{Vec3{-3, 3, 1}, Vec3{1, 0, 0}}, false, 0, Vec3{}},
+		{Ray{Vec3{-1, 6, 1}, Vec3{1, -1, 0}}, false, 0, Vec3{}},
+	}
+	for _, test := range tests {
+		tHit, n, ok := test.ray.IntersectAABB(b)
+		if ok != test.ok || tHit != test.t || n != test.normal {
+			t.Errorf("%v: Expected %v %v %v but got %v %v %v", test.ray, test.ok, test.t, test.normal, ok, tHit, n)
+		}
+	}
+}
+
+func Test_RayPlane(t *testing.T) {
+	n, d := Vec3{0, 2, 0}, float32(4) // y = 2
+	tHit, normal, ok := Ray{Vec3{1, 5, 0}, Vec3{0, -1, 0}}.IntersectPlane(n, d)
+	if !ok || tHit != 3 || normal != (Vec3{0, 1, 0}) {
+		t.Errorf("Expected a hit from above but got %v %v %v", ok, tHit, normal)
+	}
+	tHit, normal, ok = Ray{Vec3{0, 0, 0}, Vec3{1, 1, 0}}.IntersectPlane(n, d)
+	if !ok || tHit != 2 || normal != (Vec3{0, -1, 0}) {
+		t.Errorf("Expected a hit from below but got %v %v %v", ok, tHit, normal)
+	}
+	if _, _, ok := (Ray{Vec3{0, 0, 0}, Vec3{1, 0, 0}}).IntersectPlane(n, d); ok {
+		t.Errorf("Expected a parallel ray to miss")
+	}
+	if _, _, ok := (Ray{Vec3{0, 0, 0}, Vec3{0, -1, 0}}).IntersectPlane(n, d); ok {
+		t.Errorf("Expected a ray pointing away to miss")
+	}
+}
+
+func Test_RaySphere(t *testing.T) {
+	center := Vec3{0, 0, -5}
+	tHit, normal, ok := Ray{Vec3{0, 0, 0}, Vec3{0, 0, -1}}.IntersectSphere(center, 2)
+	if !ok || !FloatEqual(tHit, 3) || !vec3Near(normal, Vec3{0, 0, 1}) {
+		t.Errorf("Expected a hit at 3 but got %v %v %v", ok, tHit, normal)
+	}
+	tHit, normal, ok = Ray{Vec3{0, 0, -5}, Vec3{2, 0, 0}}.IntersectSphere(center, 2)
+	if !ok || !FloatEqual(tHit, 1) || !vec3Near(normal, Vec3{1, 0, 0}) {
+		t.Errorf("Expected a ray from the center to hit at 1 but got %v %v %v", ok, tHit, normal)
+	}
+	if _, _, ok := (Ray{Vec3{0, 3, 0}, Vec3{0, 0, -1}}).IntersectSphere(center, 2); ok {
+		t.Errorf("Expected a ray passing the sphere to miss")
+	}
+	if _, _, ok := (Ray{Vec3{0, 0, 0}, Vec3{0, 0, 1}}).IntersectSphere(center, 2); ok {
+		t.Errorf("Expected a ray pointing away to miss")
+	}
+	if p := (Ray{Vec3{1, 2, 3}, Vec3{0, 2, 0}}).At(1.5); p != (Vec3{1, 5, 3}) {
+		t.Errorf("At returned %v", p)
+	}
+}
@@ -24,3 +24,6 @@ func (v1 Vec4) Add(v2 Vec4) Vec4 {
 func (v1 Vec4) Sub(v2 Vec4) Vec4 {
 	return Vec4{v1[0] - v2[0], v1[1] - v2[1], v1[2] - v2[2], v1[3] - v2[3]}
 }
+func (v1 Vec4) Mul(c float32) Vec4 {
+	return Vec4{v1[0] * c, v1[1] * c, v1[2] * c, v1[3] * c}
+}
@@ -47,3 +47,10 @@ func Test_Vec4XYZW(t *testing.T) {
 		t.Error("Coord functions of Vec4 missmatches")
 	}
 }
+
+func Test_Vec4Mul(t *testing.T) {
+	v := Vec4{1.0, -2.5, 0, 4}.Mul(2)
+	if !FloatEqual(v[0], 2) || !FloatEqual(v[1], -5) || !FloatEqual(v[2], 0) || !FloatEqual(v[3], 8) {
+		t.Errorf("Mul not multiplying properly")
+	}
+}
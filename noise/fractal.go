@@ -0,0 +1,163 @@
+package noise
+
+// octaveOffset moves the octaves of fractal noise against each other so that
+// the lattices of the octaves don't meet at the origin.
+const octaveOffset = 19.19
+
+// FBM sums octaves of a noise function with increasing frequency and
+// decreasing amplitude (fractional Brownian motion). The result is
+// normalized to the range of the source.
+type FBM struct {
+	Source  Noise
+	Octaves int
+	// Lacunarity is the factor the frequency grows per octave.
+	Lacunarity float32
+	// Gain is the factor the amplitude shrinks per octave.
+	Gain float32
+}
+
+// NewFBM creates fractal noise with the given number of octaves which double
+// the frequency and halve the amplitude.
+func NewFBM(src Noise, octaves int) *FBM {
+	return &FBM{
+		Source:     src,
+		Octaves:    octaves,
+		Lacunarity: 2,
+		Gain:       0.5,
+	}
+}
+
+func (f *FBM) Eval2(x, y float32) float32 {
+	return f.eval(2, [4]float32{x, y})
+}
+
+func (f *FBM) Eval3(x, y, z float32) float32 {
+	return f.eval(3, [4]float32{x, y, z})
+}
+
+func (f *FBM) Eval4(x, y, z, w float32) float32 {
+	return f.eval(4, [4]float32{x, y, z, w})
+}
+
+func (f *FBM) eval(dims int, p [4]float32) float32 {
+	var sum, norm float32
+	amp, freq := float32(1), float32(1)
+	for o := 0; o < f.Octaves; o++ {
+		sum += amp * eval(f.Source, dims, octave(p, freq, o))
+		norm += amp
+		amp *= f.Gain
+		freq *= f.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// octave returns the position scaled by the frequency of the octave.
+func octave(p [4]float32, freq float32, o int) [4]float32 {
+	for i := range p {
+		p[i] = p[i]*freq + float32(o)*octaveOffset
+	}
+	return p
+}
+
+// Ridged is fractal noise with sharp ridges where the source crosses zero,
+// as used for mountain ranges. Each octave is weighted by the previous one
+// so that the valleys stay smooth. The result is in the range -1 to 1.
+type Ridged struct {
+	Source  Noise
+	Octaves int
+	// Lacunarity is the factor the frequency grows per octave.
+	Lacunarity float32
+	// Gain is the factor the amplitude shrinks per octave.
+	Gain float32
+}
+
+// NewRidged creates ridged noise with the given number of octaves which
+// double the frequency and halve the amplitude.
+func NewRidged(src Noise, octaves int) *Ridged {
+	return &Ridged{
+		Source:     src,
+		Octaves:    octaves,
+		Lacunarity: 2,
+		Gain:       0.5,
+	}
+}
+
+func (r *Ridged) Eval2(x, y float32) float32 {
+	return r.eval(2, [4]float32{x, y})
+}
+
+func (r *Ridged) Eval3(x, y, z float32) float32 {
+	return r.eval(3, [4]float32{x, y, z})
+}
+
+func (r *Ridged) Eval4(x, y, z, w float32) float32 {
+	return r.eval(4, [4]float32{x, y, z, w})
+}
+
+func (r *Ridged) eval(dims int, p [4]float32) float32 {
+	var sum, norm float32
+	amp, freq, weight := float32(1), float32(1), float32(1)
+	for o := 0; o < r.Octaves; o++ {
+		v := eval(r.Source, dims, octave(p, freq, o))
+		if v < 0 {
+			v = -v
+		}
+		signal := (1 - v) * (1 - v) * weight
+		weight = signal * 2
+		if weight > 1 {
+			weight = 1
+		} else if weight < 0 {
+			weight = 0
+		}
+		sum += amp * signal
+		norm += amp
+		amp *= r.Gain
+		freq *= r.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum/norm*2 - 1
+}
+
+// warpOffsets decorrelate the warp noise of the different axes.
+var warpOffsets = [4][4]float32{
+	{0, 0, 0, 0},
+	{5.2, 1.3, 7.7, 3.1},
+	{9.1, 4.7, 2.9, 8.3},
+	{1.7, 9.2, 5.8, 6.4},
+}
+
+// DomainWarp evaluates Source at a position displaced by Warp. Every axis is
+// displaced by Strength times a different sample of Warp.
+type DomainWarp struct {
+	Source, Warp Noise
+	Strength     float32
+}
+
+func (dw *DomainWarp) Eval2(x, y float32) float32 {
+	return dw.eval(2, [4]float32{x, y})
+}
+
+func (dw *DomainWarp) Eval3(x, y, z float32) float32 {
+	return dw.eval(3, [4]float32{x, y, z})
+}
+
+func (dw *DomainWarp) Eval4(x, y, z, w float32) float32 {
+	return dw.eval(4, [4]float32{x, y, z, w})
+}
+
+func (dw *DomainWarp) eval(dims int, p [4]float32) float32 {
+	warped := p
+	for axis := 0; axis < dims; axis++ {
+		q := p
+		for i := 0; i < dims; i++ {
+			q[i] += warpOffsets[axis][i]
+		}
+		warped[axis] += dw.Strength * eval(dw.Warp, dims, q)
+	}
+	return eval(dw.Source, dims, warped)
+}
@@ -0,0 +1,69 @@
+package noise
+
+import "math"
+
+// The gradient tables contain unit vectors spread evenly in all directions.
+var (
+	gradients2 [24][2]float32
+	gradients3 [20][3]float32
+	gradients4 [32][4]float32
+)
+
+func init() {
+	for i := range gradients2 {
+		a := (float64(i) + 0.5) * 2 * math.Pi / float64(len(gradients2))
+		gradients2[i] = [2]float32{float32(math.Cos(a)), float32(math.Sin(a))}
+	}
+
+	// the edges and corners of a cube
+	i := 0
+	for _, a := range []float32{-1, 1} {
+		for _, b := range []float32{-1, 1} {
+			edge := float32(1 / math.Sqrt2)
+			gradients3[i+0] = [3]float32{0, a * edge, b * edge}
+			gradients3[i+1] = [3]float32{a * edge, 0, b * edge}
+			gradients3[i+2] = [3]float32{a * edge, b * edge, 0}
+			i += 3
+			for _, c := range []float32{-1, 1} {
+				corner := float32(1 / math.Sqrt(3))
+				gradients3[i] = [3]float32{a * corner, b * corner, c * corner}
+				i++
+			}
+		}
+	}
+
+	// the permutations of (0, ±1, ±1, ±1)
+	i = 0
+	edge := float32(1 / math.Sqrt(3))
+	for zero := 0; zero < 4; zero++ {
+		for signs := 0; signs < 8; signs++ {
+			bit := 0
+			for axis := 0; axis < 4; axis++ {
+				if axis == zero {
+					continue
+				}
+				if signs&(1<<uint(bit)) != 0 {
+					gradients4[i][axis] = edge
+				} else {
+					gradients4[i][axis] = -edge
+				}
+				bit++
+			}
+			i++
+		}
+	}
+}
+
+// gradient returns the dot product of d with the gradient selected by h.
+func gradient(dims int, h uint64, d [4]float32) float32 {
+	switch dims {
+	case 2:
+		g := gradients2[h%uint64(len(gradients2))]
+		return g[0]*d[0] + g[1]*d[1]
+	case 3:
+		g := gradients3[h%uint64(len(gradients3))]
+		return g[0]*d[0] + g[1]*d[1] + g[2]*d[2]
+	}
+	g := gradients4[h%uint64(len(gradients4))]
+	return g[0]*d[0] + g[1]*d[1] + g[2]*d[2] + g[3]*d[3]
+}
@@ -0,0 +1,121 @@
+// Package noise provides seeded gradient and cellular noise functions and
+// combinators to build terrain or textures from them.
+//
+// All noise functions are deterministic for a seed and safe for concurrent
+// use.
+package noise
+
+import (
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// Noise is a noise function of two, three or four dimensions. Gradient noise
+// returns values in the range -1 to 1.
+type Noise interface {
+	Eval2(x, y float32) float32
+	Eval3(x, y, z float32) float32
+	Eval4(x, y, z, w float32) float32
+}
+
+// eval calls the method of n matching the number of dimensions.
+func eval(n Noise, dims int, p [4]float32) float32 {
+	switch dims {
+	case 2:
+		return n.Eval2(p[0], p[1])
+	case 3:
+		return n.Eval3(p[0], p[1], p[2])
+	}
+	return n.Eval4(p[0], p[1], p[2], p[3])
+}
+
+const (
+	primeX = 0x5205402B9270C86F
+	primeY = 0x598CD327003817B5
+	primeZ = 0x5BCC226E9FA0BACB
+	primeW = 0x56CC5227E58F554B
+)
+
+// mix is the finalizer of splitmix64.
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 27
+	h *= 0x94D049BB133111EB
+	h ^= h >> 31
+	return h
+}
+
+// hash returns a pseudo random number for the lattice point.
+func hash(seed uint64, p [4]int64) uint64 {
+	return mix(seed ^ uint64(p[0])*primeX ^ uint64(p[1])*primeY ^ uint64(p[2])*primeZ ^ uint64(p[3])*primeW)
+}
+
+// seedOf turns a seed into the internal state of the noise functions so that
+// similar seeds result in unrelated noise.
+func seedOf(seed int64) uint64 {
+	return mix(uint64(seed) + 0x9E3779B97F4A7C15)
+}
+
+func floor(f float32) int64 {
+	return int64(math.Floor(float64(f)))
+}
+
+// Fill2 evaluates the noise on a grid of sizeX * sizeY points starting at
+// the origin with a distance of step between the points. The values are
+// stored in dst with x varying fastest. The rows are evaluated in parallel.
+func Fill2(n Noise, dst []float32, sizeX, sizeY int, origin [2]float32, step float32) {
+	parallel(sizeY, func(y int) {
+		row := dst[y*sizeX : (y+1)*sizeX]
+		py := origin[1] + float32(y)*step
+		for x := range row {
+			row[x] = n.Eval2(origin[0]+float32(x)*step, py)
+		}
+	})
+}
+
+// Fill3 evaluates the noise for every voxel of a chunk of the given size
+// starting at origin with a distance of step between the voxels. The values
+// are stored in dst in the order of the rle package: x varies fastest,
+// followed by y and z. The slices along z are evaluated in parallel.
+func Fill3(n Noise, dst []float32, size mgl.Vec3I, origin mgl.Vec3, step float32) {
+	sliceSize := size.X() * size.Y()
+	parallel(size.Z(), func(z int) {
+		slice := dst[z*sliceSize : (z+1)*sliceSize]
+		pz := origin.Z() + float32(z)*step
+		for y := 0; y < size.Y(); y++ {
+			py := origin.Y() + float32(y)*step
+			row := slice[y*size.X() : (y+1)*size.X()]
+			for x := range row {
+				row[x] = n.Eval3(origin.X()+float32(x)*step, py, pz)
+			}
+		}
+	})
+}
+
+// parallel calls fn for 0 to n-1 using one goroutine per CPU.
+func parallel(n int, fn func(i int)) {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
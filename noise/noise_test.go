@@ -0,0 +1,250 @@
+package noise
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+var testNoises = map[string]func(seed int64) Noise{
+	"Perlin":      func(seed int64) Noise { return NewPerlin(seed) },
+	"OpenSimplex": func(seed int64) Noise { return NewOpenSimplex(seed) },
+	"Worley":      func(seed int64) Noise { return NewWorley(seed) },
+	"FBM":         func(seed int64) Noise { return NewFBM(NewOpenSimplex(seed), 4) },
+	"Ridged":      func(seed int64) Noise { return NewRidged(NewPerlin(seed), 4) },
+	"DomainWarp": func(seed int64) Noise {
+		return &DomainWarp{Source: NewPerlin(seed), Warp: NewOpenSimplex(seed + 1), Strength: 0.5}
+	},
+}
+
+func randomPoint(r *rand.Rand) [4]float32 {
+	return [4]float32{
+		(r.Float32() - 0.5) * 200,
+		(r.Float32() - 0.5) * 200,
+		(r.Float32() - 0.5) * 200,
+		(r.Float32() - 0.5) * 200,
+	}
+}
+
+func Test_NoiseDeterministic(t *testing.T) {
+	for name, create := range testNoises {
+		n1, n2, other := create(42), create(42), create(43)
+		r := rand.New(rand.NewSource(1))
+		for dims := 2; dims <= 4; dims++ {
+			differs := false
+			for i := 0; i < 100; i++ {
+				p := randomPoint(r)
+				v := eval(n1, dims, p)
+				if v2 := eval(n2, dims, p); v != v2 {
+					t.Fatalf("%s %dD: Expected equal values for the same seed but got %v and %v", name, dims, v, v2)
+				}
+				if eval(other, dims, p) != v {
+					differs = true
+				}
+			}
+			if !differs {
+				t.Errorf("%s %dD: Expected different seeds to result in different noise", name, dims)
+			}
+		}
+	}
+}
+
+func Test_NoiseRange(t *testing.T) {
+	for _, name := range []string{"Perlin", "OpenSimplex", "FBM", "Ridged", "DomainWarp"} {
+		n := testNoises[name](7)
+		r := rand.New(rand.NewSource(2))
+		for dims := 2; dims <= 4; dims++ {
+			lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+			for i := 0; i < 20000; i++ {
+				v := eval(n, dims, randomPoint(r))
+				if v < lo {
+					lo = v
+				}
+				if v > hi {
+					hi = v
+				}
+			}
+			if lo < -1 || hi > 1 {
+				t.Errorf("%s %dD: Values out of range [%v, %v]", name, dims, lo, hi)
+			}
+			if hi-lo < 0.8 {
+				t.Errorf("%s %dD: Expected the values to spread but got [%v, %v]", name, dims, lo, hi)
+			}
+		}
+	}
+}
+
+func Test_NoiseContinuous(t *testing.T) {
+	for name, create := range testNoises {
+		n := create(3)
+		r := rand.New(rand.NewSource(3))
+		for dims := 2; dims <= 4; dims++ {
+			for i := 0; i < 1000; i++ {
+				p := randomPoint(r)
+				q := p
+				q[i%dims] += 1e-3
+				if d := eval(n, dims, p) - eval(n, dims, q); d > 0.05 || d < -0.05 {
+					t.Fatalf("%s %dD: Noise jumps by %v at %v", name, dims, d, p)
+				}
+			}
+		}
+	}
+}
+
+func Test_PerlinLattice(t *testing.T) {
+	p := NewPerlin(1)
+	for x := -3; x < 3; x++ {
+		for y := -3; y < 3; y++ {
+			if v := p.Eval3(float32(x), float32(y), 5); v != 0 {
+				t.Errorf("Expected 0 at lattice point %d, %d but got %v", x, y, v)
+			}
+		}
+	}
+}
+
+func Test_Worley(t *testing.T) {
+	w := NewWorley(5)
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 1000; i++ {
+		p := randomPoint(r)
+		for dims := 2; dims <= 4; dims++ {
+			w.Mode = F1
+			f1 := eval(w, dims, p)
+			w.Mode = F2
+			f2 := eval(w, dims, p)
+			w.Mode = F2MinusF1
+			diff := eval(w, dims, p)
+			w.Mode = CellValue
+			cell := eval(w, dims, p)
+			if f1 < 0 || f2 < f1 || diff != f2-f1 || cell < -1 || cell > 1 {
+				t.Fatalf("%dD: Invalid results F1 %v F2 %v F2-F1 %v cell %v", dims, f1, f2, diff, cell)
+			}
+		}
+	}
+
+	// compare F2 with all feature points of the cells up to 3 cells away
+	w.Mode = F2
+	for i := 0; i < 1000; i++ {
+		p := randomPoint(r)
+		for dims := 2; dims <= 3; dims++ {
+			var cell [4]int64
+			for j := 0; j < dims; j++ {
+				cell[j] = floor(p[j])
+			}
+			var dists []float32
+			var walk func(axis int, c [4]int64)
+			walk = func(axis int, c [4]int64) {
+				if axis == dims {
+					f, _ := w.feature(dims, c)
+					var d [4]float32
+					for j := 0; j < dims; j++ {
+						d[j] = f[j] - p[j]
+					}
+					dists = append(dists, w.Metric.distance(dims, d))
+					return
+				}
+				for o := int64(-3); o <= 3; o++ {
+					c[axis] = cell[axis] + o
+					walk(axis+1, c)
+				}
+			}
+			walk(0, cell)
+			sort.Slice(dists, func(i, j int) bool { return dists[i] < dists[j] })
+			if f2 := eval(w, dims, p); f2 != dists[1] {
+				t.Fatalf("%dD: F2 at %v is %v expected %v", dims, p, f2, dists[1])
+			}
+		}
+	}
+
+	grid := NewWorley(5)
+	grid.Jitter = 0
+	grid.Metric = Manhattan
+	if v := grid.Eval2(3, -2); v != 0 {
+		t.Errorf("Expected a feature point at every integer position but got %v", v)
+	}
+	if v := grid.Eval2(3.5, -2.25); v != 0.75 {
+		t.Errorf("Expected the manhattan distance 0.75 but got %v", v)
+	}
+	grid.Metric = Chebyshev
+	if v := grid.Eval3(3.5, -2.25, 0.1); v != 0.5 {
+		t.Errorf("Expected the chebyshev distance 0.5 but got %v", v)
+	}
+}
+
+func Test_FractalIdentity(t *testing.T) {
+	src := NewOpenSimplex(9)
+	fbm := NewFBM(src, 1)
+	warp := &DomainWarp{Source: src, Warp: NewPerlin(1), Strength: 0}
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		p := randomPoint(r)
+		exp := src.Eval3(p[0], p[1], p[2])
+		if v := fbm.Eval3(p[0], p[1], p[2]); v != exp {
+			t.Errorf("A single octave should return the source but got %v instead of %v", v, exp)
+		}
+		if v := warp.Eval3(p[0], p[1], p[2]); v != exp {
+			t.Errorf("Warping with strength 0 should return the source but got %v instead of %v", v, exp)
+		}
+	}
+	if v := NewFBM(src, 0).Eval2(1, 2); v != 0 {
+		t.Errorf("Expected 0 without octaves but got %v", v)
+	}
+}
+
+func Test_Fill(t *testing.T) {
+	n := NewPerlin(11)
+	size := mgl.Vec3I{5, 4, 3}
+	origin := mgl.Vec3{-1, 2, 0.5}
+	dst := make([]float32, size.X()*size.Y()*size.Z())
+	Fill3(n, dst, size, origin, 0.25)
+	for z := 0; z < size.Z(); z++ {
+		for y := 0; y < size.Y(); y++ {
+			for x := 0; x < size.X(); x++ {
+				exp := n.Eval3(origin.X()+float32(x)*0.25, origin.Y()+float32(y)*0.25, origin.Z()+float32(z)*0.25)
+				if v := dst[(z*size.Y()+y)*size.X()+x]; v != exp {
+					t.Fatalf("Expected %v at %d, %d, %d but got %v", exp, x, y, z, v)
+				}
+			}
+		}
+	}
+
+	dst2 := make([]float32, 6*7)
+	Fill2(n, dst2, 6, 7, [2]float32{3, -4}, 0.5)
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 6; x++ {
+			if exp := n.Eval2(3+float32(x)*0.5, -4+float32(y)*0.5); dst2[y*6+x] != exp {
+				t.Fatalf("Expected %v at %d, %d but got %v", exp, x, y, dst2[y*6+x])
+			}
+		}
+	}
+}
+
+func benchmarkNoise3(b *testing.B, n Noise) {
+	for i := 0; i < b.N; i++ {
+		n.Eval3(float32(i)*0.37, float32(i)*0.11, float32(i)*0.23)
+	}
+}
+
+func BenchmarkPerlin3(b *testing.B) {
+	benchmarkNoise3(b, NewPerlin(1))
+}
+
+func BenchmarkOpenSimplex3(b *testing.B) {
+	benchmarkNoise3(b, NewOpenSimplex(1))
+}
+
+func BenchmarkWorley3(b *testing.B) {
+	benchmarkNoise3(b, NewWorley(1))
+}
+
+func BenchmarkFill3(b *testing.B) {
+	n := NewFBM(NewOpenSimplex(1), 4)
+	size := mgl.Vec3I{32, 32, 32}
+	dst := make([]float32, 32*32*32)
+	for i := 0; i < b.N; i++ {
+		Fill3(n, dst, size, mgl.Vec3{}, 0.05)
+	}
+}
@@ -0,0 +1,137 @@
+package noise
+
+import "math"
+
+// OpenSimplex is gradient noise on simplex type lattices in the style of
+// OpenSimplex2. It has fewer directional artifacts than Perlin noise. 2D and
+// 4D noise use the skewed simplex lattice, 3D noise uses a rotated body
+// centered cubic lattice. The values are not compatible with other
+// implementations.
+type OpenSimplex struct {
+	seed uint64
+}
+
+// NewOpenSimplex creates OpenSimplex noise for the seed.
+func NewOpenSimplex(seed int64) *OpenSimplex {
+	return &OpenSimplex{seedOf(seed)}
+}
+
+var (
+	skew2   = float32((math.Sqrt(3) - 1) / 2)
+	unskew2 = float32((3 - math.Sqrt(3)) / 6)
+	skew4   = float32((math.Sqrt(5) - 1) / 4)
+	unskew4 = float32((5 - math.Sqrt(5)) / 20)
+)
+
+// simplexScale maps the values of the noise to the range -1 to 1. The
+// factors are based on the largest values found by sampling.
+var simplexScale = [5]float32{2: 1 / 0.0101, 3: 1 / 0.0253, 4: 1 / 0.0214}
+
+// contribution returns the falloff of a lattice point at the offset d
+// multiplied with its gradient.
+func (n *OpenSimplex) contribution(dims int, seed uint64, p [4]int64, d [4]float32, rSquared float32) float32 {
+	a := rSquared
+	for i := 0; i < dims; i++ {
+		a -= d[i] * d[i]
+	}
+	if a <= 0 {
+		return 0
+	}
+	a *= a
+	return a * a * gradient(dims, hash(seed, p), d)
+}
+
+func (n *OpenSimplex) Eval2(x, y float32) float32 {
+	s := (x + y) * skew2
+	i, j := floor(x+s), floor(y+s)
+	t := float32(i+j) * unskew2
+	x0, y0 := x-(float32(i)-t), y-(float32(j)-t)
+
+	// the middle corner of the triangle containing the point
+	var i1, j1 int64 = 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+
+	v := n.contribution(2, n.seed, [4]int64{i, j}, [4]float32{x0, y0}, 0.5)
+	v += n.contribution(2, n.seed, [4]int64{i + i1, j + j1},
+		[4]float32{x0 - float32(i1) + unskew2, y0 - float32(j1) + unskew2}, 0.5)
+	v += n.contribution(2, n.seed, [4]int64{i + 1, j + 1},
+		[4]float32{x0 - 1 + 2*unskew2, y0 - 1 + 2*unskew2}, 0.5)
+	return v * simplexScale[2]
+}
+
+func (n *OpenSimplex) Eval3(x, y, z float32) float32 {
+	// rotating the input hides the cubic structure of the lattice.
+	r := (x + y + z) * (2.0 / 3.0)
+	p := [3]float32{r - x, r - y, r - z}
+
+	// The lattice consists of the integer points and a second cubic lattice
+	// shifted by one half on every axis.
+	var v float32
+	for lattice := 0; lattice < 2; lattice++ {
+		offset := float32(lattice) * 0.5
+		seed := n.seed
+		if lattice == 1 {
+			seed = mix(seed ^ 0xA5A5A5A5A5A5A5A5)
+		}
+		var cell [4]int64
+		for i := 0; i < 3; i++ {
+			cell[i] = floor(p[i] - offset)
+		}
+		for c := 0; c < 8; c++ {
+			corner := cell
+			var d [4]float32
+			for i := 0; i < 3; i++ {
+				corner[i] += int64((c >> uint(i)) & 1)
+				d[i] = p[i] - float32(corner[i]) - offset
+			}
+			v += n.contribution(3, seed, corner, d, 0.6)
+		}
+	}
+	return v * simplexScale[3]
+}
+
+func (n *OpenSimplex) Eval4(x, y, z, w float32) float32 {
+	p := [4]float32{x, y, z, w}
+	s := (x + y + z + w) * skew4
+	var cell [4]int64
+	var t float32
+	for i := range p {
+		cell[i] = floor(p[i] + s)
+		t += float32(cell[i])
+	}
+	t *= unskew4
+	var d0 [4]float32
+	for i := range p {
+		d0[i] = p[i] - (float32(cell[i]) - t)
+	}
+
+	// rank the axes by the size of the offset. The simplex containing the
+	// point steps along the axes from the largest to the smallest offset.
+	var rank [4]int
+	for i := 0; i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			if d0[i] > d0[j] {
+				rank[i]++
+			} else {
+				rank[j]++
+			}
+		}
+	}
+
+	var v float32
+	for k := 0; k <= 4; k++ {
+		corner := cell
+		var d [4]float32
+		for i := range p {
+			// the corner k steps along the k axes with the highest rank
+			if rank[i] >= 4-k {
+				corner[i]++
+			}
+			d[i] = d0[i] - float32(corner[i]-cell[i]) + float32(k)*unskew4
+		}
+		v += n.contribution(4, n.seed, corner, d, 0.6)
+	}
+	return v * simplexScale[4]
+}
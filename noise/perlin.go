@@ -0,0 +1,64 @@
+package noise
+
+// Perlin is the improved gradient noise of Ken Perlin.
+type Perlin struct {
+	seed uint64
+}
+
+// NewPerlin creates Perlin noise for the seed.
+func NewPerlin(seed int64) *Perlin {
+	return &Perlin{seedOf(seed)}
+}
+
+// perlinScale maps the values of the noise to the range -1 to 1. The factors
+// are based on the largest values found by sampling.
+var perlinScale = [5]float32{2: 1 / 0.708, 3: 1 / 0.8, 4: 1 / 0.71}
+
+func (p *Perlin) Eval2(x, y float32) float32 {
+	return p.eval(2, [4]float32{x, y})
+}
+
+func (p *Perlin) Eval3(x, y, z float32) float32 {
+	return p.eval(3, [4]float32{x, y, z})
+}
+
+func (p *Perlin) Eval4(x, y, z, w float32) float32 {
+	return p.eval(4, [4]float32{x, y, z, w})
+}
+
+func (p *Perlin) eval(dims int, pos [4]float32) float32 {
+	var cell [4]int64
+	var frac, fade [4]float32
+	for i := 0; i < dims; i++ {
+		cell[i] = floor(pos[i])
+		frac[i] = pos[i] - float32(cell[i])
+		fade[i] = smootherstep(frac[i])
+	}
+
+	// the gradients of the corners of the cell. Bit i of the index selects
+	// the corner along axis i.
+	var values [16]float32
+	corners := 1 << uint(dims)
+	for c := 0; c < corners; c++ {
+		corner := cell
+		var d [4]float32
+		for i := 0; i < dims; i++ {
+			bit := (c >> uint(i)) & 1
+			corner[i] += int64(bit)
+			d[i] = frac[i] - float32(bit)
+		}
+		values[c] = gradient(dims, hash(p.seed, corner), d)
+	}
+	// interpolate along one axis after the other.
+	for i := 0; i < dims; i++ {
+		corners >>= 1
+		for c := 0; c < corners; c++ {
+			values[c] = values[2*c] + fade[i]*(values[2*c+1]-values[2*c])
+		}
+	}
+	return values[0] * perlinScale[dims]
+}
+
+func smootherstep(t float32) float32 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
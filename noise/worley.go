@@ -0,0 +1,161 @@
+package noise
+
+import "math"
+
+// Metric measures the distance between points.
+type Metric int
+
+const (
+	Euclidean Metric = iota
+	Manhattan
+	Chebyshev
+)
+
+func (m Metric) distance(dims int, d [4]float32) float32 {
+	var res float32
+	for i := 0; i < dims; i++ {
+		switch m {
+		case Manhattan:
+			res += float32(math.Abs(float64(d[i])))
+		case Chebyshev:
+			if a := float32(math.Abs(float64(d[i]))); a > res {
+				res = a
+			}
+		default:
+			res += d[i] * d[i]
+		}
+	}
+	if m == Euclidean {
+		return float32(math.Sqrt(float64(res)))
+	}
+	return res
+}
+
+// WorleyMode selects the result of Worley noise.
+type WorleyMode int
+
+const (
+	// F1 is the distance to the closest feature point.
+	F1 WorleyMode = iota
+	// F2 is the distance to the second closest feature point.
+	F2
+	// F2MinusF1 is the difference of F2 and F1 which is zero at the borders
+	// of the cells.
+	F2MinusF1
+	// CellValue is a random value from -1 to 1 for the cell of the closest
+	// feature point.
+	CellValue
+)
+
+// Worley is cellular noise based on the distance to random feature points.
+// There is one feature point for each unit cell. Apart from CellValue the
+// results are distances which are mostly within the range 0 to 1.
+type Worley struct {
+	seed   uint64
+	Metric Metric
+	Mode   WorleyMode
+	// Jitter is the amount the feature points are moved from the corner of
+	// their cell. 1 places them anywhere within the cell, 0 results in a
+	// regular grid.
+	Jitter float32
+}
+
+// NewWorley creates Worley noise returning the euclidean distance to the
+// closest feature point.
+func NewWorley(seed int64) *Worley {
+	return &Worley{
+		seed:   seedOf(seed),
+		Metric: Euclidean,
+		Mode:   F1,
+		Jitter: 1,
+	}
+}
+
+func (wn *Worley) Eval2(x, y float32) float32 {
+	return wn.eval(2, [4]float32{x, y})
+}
+
+func (wn *Worley) Eval3(x, y, z float32) float32 {
+	return wn.eval(3, [4]float32{x, y, z})
+}
+
+func (wn *Worley) Eval4(x, y, z, w float32) float32 {
+	return wn.eval(4, [4]float32{x, y, z, w})
+}
+
+// feature returns the feature point of the cell and the hash of the cell.
+func (wn *Worley) feature(dims int, c [4]int64) ([4]float32, uint64) {
+	h := hash(wn.seed, c)
+	var f [4]float32
+	r := h
+	for i := 0; i < dims; i++ {
+		r = mix(r + 0x9E3779B97F4A7C15)
+		f[i] = float32(c[i]) + wn.Jitter*float32(r>>40)/(1<<24)
+	}
+	return f, h
+}
+
+func (wn *Worley) eval(dims int, p [4]float32) float32 {
+	var cell [4]int64
+	for i := 0; i < dims; i++ {
+		cell[i] = floor(p[i])
+	}
+
+	f1, f2 := float32(math.Inf(1)), float32(math.Inf(1))
+	var closest uint64
+	// The feature points of the cells in the ring r cells around the cell of
+	// p are more than r-1 away along one axis. The rings are searched until
+	// they can't contain a closer point, which for F2 may be two cells away.
+	for r := 1; ; r++ {
+		if needed := f1; r > 1 {
+			if wn.Mode == F2 || wn.Mode == F2MinusF1 {
+				needed = f2
+			}
+			if needed <= float32(r-1) {
+				break
+			}
+		}
+		side := 2*r + 1
+		cells := 1
+		for i := 0; i < dims; i++ {
+			cells *= side
+		}
+		for n := 0; n < cells; n++ {
+			c := cell
+			rest := n
+			onRing := r == 1
+			for i := 0; i < dims; i++ {
+				o := rest%side - r
+				if o == r || o == -r {
+					onRing = true
+				}
+				c[i] += int64(o)
+				rest /= side
+			}
+			if !onRing {
+				continue
+			}
+			f, h := wn.feature(dims, c)
+			var d [4]float32
+			for i := 0; i < dims; i++ {
+				d[i] = f[i] - p[i]
+			}
+			dist := wn.Metric.distance(dims, d)
+			if dist < f1 {
+				f1, f2, closest = dist, f1, h
+			} else if dist < f2 {
+				f2 = dist
+			}
+		}
+	}
+
+	switch wn.Mode {
+	case F2:
+		return f2
+	case F2MinusF1:
+		return f2 - f1
+	case CellValue:
+		return float32(closest>>40)/(1<<23) - 1
+	}
+	return f1
+}
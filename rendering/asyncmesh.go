@@ -0,0 +1,74 @@
+package rendering
+
+import (
+	"context"
+)
+
+// AsyncMesh renders a chunk which is meshed in the background by a Mesher.
+// Nothing is rendered until the result of the Mesher was passed to SetResult.
+type AsyncMesh struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	renderer Renderer
+	tag      interface{}
+}
+
+// NewAsyncMesh submits the request to the mesher. The request is tagged with
+// the returned AsyncMesh, its own tag is returned by the Tag method.
+func NewAsyncMesh(m *Mesher, req MeshRequest) *AsyncMesh {
+	ctx, cancel := context.WithCancel(context.Background())
+	am := &AsyncMesh{ctx: ctx, cancel: cancel, tag: req.Tag}
+	req.Tag = am
+	m.Submit(ctx, req)
+	return am
+}
+
+// SetResult uploads the mesh of the result. It has to be called on the
+// thread owning the GL context. Results arriving after Close are dropped.
+func (am *AsyncMesh) SetResult(r MeshResult) {
+	if r.Err != nil || am.ctx.Err() != nil {
+		return
+	}
+	am.closeRenderer()
+	am.renderer = r.Renderer()
+}
+
+// Tag returns the tag of the request, e.g. to compute its priority in
+// Mesher.Reprioritize.
+func (am *AsyncMesh) Tag() interface{} {
+	return am.tag
+}
+
+// Ready returns true if the mesh was uploaded.
+func (am *AsyncMesh) Ready() bool {
+	return am.renderer != nil
+}
+
+func (am *AsyncMesh) Render() {
+	if am.renderer != nil {
+		am.renderer.Render()
+	}
+}
+
+// RenderLOD renders the level of detail for the given projected size if the
+// mesh was requested with levels of detail.
+func (am *AsyncMesh) RenderLOD(screenSize float32) {
+	if lr, ok := am.renderer.(LODRenderer); ok {
+		lr.RenderLOD(screenSize)
+	} else {
+		am.Render()
+	}
+}
+
+func (am *AsyncMesh) closeRenderer() {
+	if rc, ok := am.renderer.(RenderCloser); ok {
+		rc.Close()
+	}
+	am.renderer = nil
+}
+
+// Close cancels the meshing if it is still pending and frees the mesh.
+func (am *AsyncMesh) Close() {
+	am.cancel()
+	am.closeRenderer()
+}
@@ -0,0 +1,48 @@
+package rendering
+
+import (
+	"github.com/boombuler/voxel/mgl"
+)
+
+// MeshHandle identifies a mesh created by a Backend.
+type MeshHandle uint32
+
+// Backend abstracts the graphics API used to draw meshes. All methods have to
+// be called from the thread owning the graphics context.
+type Backend interface {
+	// CreateMesh uploads the quads and returns the handle of the new mesh.
+	CreateMesh(quads []VertexF) MeshHandle
+	// UploadMesh replaces the quads of the mesh.
+	UploadMesh(h MeshHandle, quads []VertexF)
+	DeleteMesh(h MeshHandle)
+	DrawMesh(h MeshHandle)
+	// DrawQuads draws the quads without creating a mesh.
+	DrawQuads(quads []VertexF)
+
+	// Clear clears the color and depth buffer.
+	Clear()
+	SetProjection(m mgl.Mat4)
+	Projection() mgl.Mat4
+	SetModelView(m mgl.Mat4)
+	ModelView() mgl.Mat4
+	// PushTransform saves the model view matrix and multiplies it with m.
+	PushTransform(m mgl.Mat4)
+	// PopTransform restores the model view matrix saved by PushTransform.
+	PopTransform()
+}
+
+// PackedBackend is implemented by backends which can draw packed vertices.
+type PackedBackend interface {
+	Backend
+	// CreatePackedMesh uploads the vertices and the palette their colors
+	// refer to and returns the handle of the new mesh.
+	CreatePackedMesh(verts []VertexP, palette []Color) (MeshHandle, error)
+}
+
+// checkBackend panics if no backend is set. Without it the renderers could
+// not draw anything.
+func checkBackend(b Backend) {
+	if b == nil {
+		panic("rendering: Options.Backend is nil")
+	}
+}
@@ -0,0 +1,135 @@
+package rendering
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_RenderedChunkLifecycle(t *testing.T) {
+	b := NewRecordingBackend()
+	r := NewRenderedChunk(stairChunk(), Options{Backend: b})
+	r.Render()
+	r.(RenderCloser).Close()
+
+	ops := []BackendOp{OpCreateMesh, OpDrawMesh, OpDeleteMesh}
+	if len(b.Calls) != len(ops) {
+		t.Fatalf("Expected %v calls got %v", len(ops), b.Calls)
+	}
+	for i, op := range ops {
+		if b.Calls[i].Op != op {
+			t.Errorf("Call %v is %v expected %v", i, b.Calls[i].Op, op)
+		}
+	}
+	if b.LiveMeshes() != 0 {
+		t.Errorf("Mesh was not deleted")
+	}
+
+	b.Reset()
+	NewRenderedChunk(stairChunk(), Options{Backend: b, NoVBO: true}).Render()
+	if b.Count(OpDrawQuads) != 1 || b.LiveMeshes() != 0 {
+		t.Errorf("Immediate mode should not create meshes: %v", b.Calls)
+	}
+
+	b.Reset()
+	NewRenderedChunk(stairChunk(), Options{Backend: b, Packed: true}).Render()
+	if b.Count(OpCreateMesh) != 1 || b.Count(OpDrawMesh) != 1 {
+		t.Errorf("Packed mesh was not drawn: %v", b.Calls)
+	}
+}
+
+func Test_PackedFallback(t *testing.T) {
+	b := NewRecordingBackend()
+	buf := new(bytes.Buffer)
+	tc := filledChunk(mgl.Vec3I{256, 1, 1}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	NewRenderedChunk(tc, Options{Backend: b, Packed: true, Logger: slog.New(slog.NewTextHandler(buf, nil))}).Render()
+	if b.Count(OpDrawMesh) != 1 {
+		t.Errorf("Unpacked mesh was not drawn: %v", b.Calls)
+	}
+	if !strings.Contains(buf.String(), "unpacked") {
+		t.Errorf("Fallback to the unpacked mesh was not logged: %q", buf.String())
+	}
+}
+
+func Test_EditableMeshUploads(t *testing.T) {
+	b := NewRecordingBackend()
+	tc := stairChunk()
+	em := NewEditableMesh(tc, Options{Backend: b})
+	em.Render()
+	parts := b.Count(OpCreateMesh)
+	b.Reset()
+	em.Render()
+	if b.Count(OpCreateMesh) != 0 || b.Count(OpUploadMesh) != 0 || b.Count(OpDrawMesh) != parts {
+		t.Errorf("Unexpected calls for unmodified mesh: %v", b.Calls)
+	}
+
+	tc.voxels[mgl.Vec3I{0, 3, 0}] = testRed
+	em.Invalidate(mgl.Vec3I{0, 3, 0})
+	b.Reset()
+	em.Render()
+	quads, uploads := 0, 0
+	for _, c := range b.Calls {
+		if c.Op == OpCreateMesh || c.Op == OpUploadMesh {
+			quads += c.Quads
+			uploads++
+		}
+	}
+	if uploads == 0 || uploads >= parts || quads >= len(em.Vertices())/4 {
+		t.Errorf("Only the modified slices should be uploaded got %v uploads with %v quads", uploads, quads)
+	}
+	em.Close()
+	if b.LiveMeshes() != 0 {
+		t.Errorf("Mesh was not deleted")
+	}
+
+	// removing T-junctions needs the whole mesh
+	em = NewEditableMesh(tc, Options{Backend: b, NoTJunctions: true})
+	b.Reset()
+	em.Render()
+	if b.Count(OpCreateMesh) != 1 || b.Calls[0].Quads != len(em.Vertices())/4 {
+		t.Errorf("Mesh without T-junctions should be uploaded at once: %v", b.Calls)
+	}
+	em.Close()
+}
+
+func Test_LODMeshTransform(t *testing.T) {
+	b := NewRecordingBackend()
+	m := NewLODMesh(stairChunk(), Options{Backend: b}, LODOptions{Levels: 1})
+	m.RenderLOD(1)
+	call := b.Calls[len(b.Calls)-1]
+	if call.Op != OpDrawMesh || call.ModelView != mgl.Identity().Scale(2, 2, 2) {
+		t.Errorf("Coarse level should be scaled: %v", call)
+	}
+	if b.ModelView() != mgl.Identity() {
+		t.Errorf("Transform was not restored")
+	}
+	m.Close()
+	if b.LiveMeshes() != 0 {
+		t.Errorf("%v meshes were not deleted", b.LiveMeshes())
+	}
+}
+
+func Test_NilBackend(t *testing.T) {
+	constructors := map[string]func(){
+		"RenderedChunk": func() { NewRenderedChunk(stairChunk(), Options{}) },
+		"EmptyChunk":    func() { NewRenderedChunk(newTestChunk(mgl.Vec3I{1, 1, 1}), Options{NoVBO: true}) },
+		"LODMesh":       func() { NewLODMesh(stairChunk(), Options{}, LODOptions{Levels: 1}) },
+		"EditableMesh":  func() { NewEditableMesh(stairChunk(), Options{}).Render() },
+		"CubeMesh":      func() { NewCubeMesh(nil, nil) },
+	}
+	for name, fn := range constructors {
+		func() {
+			defer func() {
+				if r := recover(); r != "rendering: Options.Backend is nil" {
+					t.Errorf("%v without backend should panic got %v", name, r)
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -1,25 +1,36 @@
 package rendering
 
-import (
-	"github.com/go-gl-legacy/gl"
-)
-
 func NewRenderedChunk(c Chunk, opt Options) Renderer {
-	mesh := CreateMeshFromChunk(c, opt)
+	mesh, _ := CreateMeshFromChunk(c, opt)
+	return newRendererFromMesh(c, mesh, opt)
+}
+
+func newRendererFromMesh(c Chunk, mesh []VertexF, opt Options) Renderer {
+	checkBackend(opt.Backend)
 	if len(mesh) == 0 {
 		return RenderFunc(func() {})
 	}
-	if !opt.HasFlag(NO_VBO) {
-		return NewCubeMesh(mesh)
+	if !opt.NoVBO {
+		if pb, ok := opt.Backend.(PackedBackend); ok && opt.Packed {
+			pm, err := newPackedMeshFromChunk(pb, c, mesh)
+			if err == nil {
+				return pm
+			}
+			opt.warn("mesh uploaded unpacked", "err", err)
+		}
+		return NewCubeMesh(opt.Backend, mesh)
 	} else {
 		return RenderFunc(func() {
-			gl.Begin(gl.QUADS)
-			defer gl.End()
-			for _, v := range mesh {
-				gl.Normal3f(v.Norm.X(), v.Norm.Y(), v.Norm.Z())
-				gl.Color4f(v.Color.Red, v.Color.Green, v.Color.Blue, v.Color.Alpha)
-				gl.Vertex3f(v.Pos.X(), v.Pos.Y(), v.Pos.Z())
-			}
+			opt.Backend.DrawQuads(mesh)
 		})
 	}
 }
+
+func newPackedMeshFromChunk(b PackedBackend, c Chunk, mesh []VertexF) (*PackedMesh, error) {
+	verts, palette, err := PackVertices(mesh)
+	if err != nil {
+		return nil, err
+	}
+	ApplyAmbientOcclusion(c, verts)
+	return NewPackedMesh(b, verts, palette)
+}
@@ -0,0 +1,76 @@
+package rendering
+
+import (
+	"github.com/boombuler/voxel/mgl"
+)
+
+// ClipPlane removes all voxels in front of the plane from the mesh. A voxel
+// is clipped if its center p satisfies Normal·p > Distance. The faces of the
+// voxels behind the plane which are exposed by the clipping are meshed as
+// caps of the cut.
+type ClipPlane struct {
+	Normal   mgl.Vec3
+	Distance float32
+}
+
+// ClipAbove returns a plane clipping all voxels with a center above y.
+func ClipAbove(y float32) ClipPlane {
+	return ClipPlane{mgl.Vec3{0, 1, 0}, y}
+}
+
+// ClipPlaneThrough returns a plane through the point p clipping all voxels in
+// direction n.
+func ClipPlaneThrough(p, n mgl.Vec3) ClipPlane {
+	n = n.Normalize()
+	return ClipPlane{n, n.Dot(p)}
+}
+
+// Clips checks if the voxel at the given position is clipped by the plane.
+func (cp ClipPlane) Clips(pos mgl.Vec3I) bool {
+	center := pos.Vec3().Add(mgl.Vec3{0.5, 0.5, 0.5})
+	return cp.Normal.Dot(center) > cp.Distance
+}
+
+// clippedChunk hides all voxels clipped by any of the planes.
+type clippedChunk struct {
+	Chunk
+	planes []ClipPlane
+}
+
+func clipChunk(c Chunk, planes []ClipPlane) Chunk {
+	if len(planes) == 0 {
+		return c
+	}
+	return &clippedChunk{c, planes}
+}
+
+func (cc *clippedChunk) clipped(pos mgl.Vec3I) bool {
+	for _, p := range cc.planes {
+		if p.Clips(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc *clippedChunk) At(pos mgl.Vec3I) Voxel {
+	if cc.clipped(pos) {
+		return nil
+	}
+	return cc.Chunk.At(pos)
+}
+
+func (cc *clippedChunk) ForeachVoxel(fn func(pos mgl.Vec3I, vox Voxel)) {
+	it, ok := cc.Chunk.(IteratableChunk)
+	if !ok {
+		defaultVoxelIterator(cc)(fn)
+		return
+	}
+	it.ForeachVoxel(func(pos mgl.Vec3I, vox Voxel) {
+		if cc.clipped(pos) {
+			fn(pos, nil)
+		} else {
+			fn(pos, vox)
+		}
+	})
+}
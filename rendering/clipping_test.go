@@ -0,0 +1,53 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_ClipAbove(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{3, 3, 3}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	mesh := meshOf(tc, Options{ClipPlanes: []ClipPlane{ClipAbove(2)}})
+	if q := len(mesh) / 4; q != 6 {
+		t.Errorf("Clipped cube should have 6 quads got %v", q)
+	}
+	caps := 0
+	for i := 0; i < len(mesh); i += 4 {
+		for _, v := range mesh[i : i+4] {
+			if v.Pos.Y() > 2 {
+				t.Fatalf("Vertex %v is above the clip plane", v.Pos)
+			}
+		}
+		if mesh[i].Norm.Y() == 1 && mesh[i].Pos.Y() == 2 {
+			caps++
+		}
+	}
+	if caps != 1 {
+		t.Errorf("Expected a single cap face got %v", caps)
+	}
+}
+
+func Test_ClipPlaneThrough(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{3, 3, 3}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	plane := ClipPlaneThrough(mgl.Vec3{1.5, 1.5, 1.5}, mgl.Vec3{1, 1, 1})
+	if plane.Clips(mgl.Vec3I{1, 1, 1}) || !plane.Clips(mgl.Vec3I{2, 1, 1}) {
+		t.Error("Invalid voxels clipped by the diagonal plane")
+	}
+
+	faces := unitFaces(meshOf(tc, Options{NoMeshing: true, ClipPlanes: []ClipPlane{plane}}))
+	// The voxels behind the cut expose their faces towards the clipped voxels.
+	for _, f := range []unitFace{
+		{mgl.Vec3I{2, 1, 1}, mgl.Vec3I{1, 0, 0}},
+		{mgl.Vec3I{1, 2, 1}, mgl.Vec3I{0, 1, 0}},
+		{mgl.Vec3I{1, 1, 2}, mgl.Vec3I{0, 0, 1}},
+	} {
+		if _, ok := faces[f]; !ok {
+			t.Errorf("Missing cap face %v", f)
+		}
+	}
+}
@@ -12,8 +12,6 @@ type Color struct {
 	Alpha float32
 }
 
-const color_Size int = 4 * 4
-
 func fToUiColVal(f float32) uint32 {
 	// ensure it is in range
 	ff := math.Max(float64(0), math.Min(float64(1), float64(f)))
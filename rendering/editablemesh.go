@@ -0,0 +1,253 @@
+package rendering
+
+import (
+	"sync"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type region struct {
+	min, max mgl.Vec3I
+}
+
+// meshPart is a part of an EditableMesh which is uploaded on its own.
+type meshPart struct {
+	verts   []VertexF
+	mesh    *CubeMesh
+	changed bool
+}
+
+func (p *meshPart) set(verts []VertexF) {
+	p.verts = verts
+	p.changed = true
+}
+
+// render uploads the quads of the part if they changed and draws them.
+func (p *meshPart) render(opt Options) {
+	if opt.NoVBO {
+		p.changed = false
+		if len(p.verts) > 0 {
+			opt.Backend.DrawQuads(p.verts)
+		}
+		return
+	}
+	if p.changed {
+		p.changed = false
+		switch {
+		case len(p.verts) == 0:
+			p.close()
+		case p.mesh == nil:
+			p.mesh = NewCubeMesh(opt.Backend, p.verts)
+		default:
+			p.mesh.Update(p.verts)
+		}
+	}
+	if p.mesh != nil {
+		p.mesh.Render()
+	}
+}
+
+func (p *meshPart) close() {
+	if p.mesh != nil {
+		p.mesh.Close()
+		p.mesh = nil
+	}
+}
+
+// EditableMesh renders chunks which are modified after the mesh was created.
+// The mesh is split in slices for each face direction. Modified voxels have
+// to be reported by Invalidate or InvalidateRegion and only the affected
+// slices are rebuilt on the next Update. Every slice is uploaded on its own,
+// unless T-junctions are removed which needs the whole mesh.
+type EditableMesh struct {
+	ctx    *meshContext
+	opt    Options
+	slices [6][]meshPart
+	dirty  [6]map[int]struct{}
+	// shaped contains the quads of all shaped voxels
+	shaped      map[mgl.Vec3I][]VertexF
+	dirtyShapes []region
+	// shapes is the part of the shaped voxels.
+	shapes meshPart
+	// all is the part of the whole mesh if T-junctions are removed.
+	all meshPart
+}
+
+func NewEditableMesh(c Chunk, opt Options) *EditableMesh {
+	m := &EditableMesh{
+		ctx:    newMeshContext(c, opt),
+		opt:    opt,
+		shaped: make(map[mgl.Vec3I][]VertexF),
+	}
+	bounds := m.ctx.bounds
+	for f := range m.slices {
+		m.slices[f] = make([]meshPart, bounds[faceDirection(f).axis()])
+		m.dirty[f] = make(map[int]struct{})
+	}
+	m.InvalidateRegion(mgl.Vec3I{0, 0, 0}, bounds.Sub(mgl.Vec3I{1, 1, 1}))
+	m.Update()
+	return m
+}
+
+func (ctx *meshContext) cullSlice(dir faceDirection, idx int) map[mgl.Vec3I]Voxel {
+	result := make(map[mgl.Vec3I]Voxel)
+	bounds := ctx.bounds
+	a := dir.axis()
+	u, v := (a+1)%3, (a+2)%3
+
+	var p mgl.Vec3I
+	p[a] = idx
+	for p[u] = 0; p[u] < bounds[u]; p[u]++ {
+		for p[v] = 0; p[v] < bounds[v]; p[v]++ {
+			vox := ctx.chunk.At(p)
+			if !IsVoxelInvisible(vox) && shapeOf(vox) == nil && ctx.isFaceVisible(p, vox, dir) {
+				result[p] = vox
+			}
+		}
+	}
+	return result
+}
+
+func (m *EditableMesh) markDirty(dir faceDirection, idx int) {
+	if idx >= 0 && idx < len(m.slices[dir]) {
+		m.dirty[dir][idx] = struct{}{}
+	}
+}
+
+// Invalidate marks the voxel at the given position as modified.
+func (m *EditableMesh) Invalidate(pos mgl.Vec3I) {
+	m.InvalidateRegion(pos, pos)
+}
+
+// InvalidateRegion marks all voxels between min and max (inclusive) as modified.
+func (m *EditableMesh) InvalidateRegion(min, max mgl.Vec3I) {
+	for f := faceDirection(0); f < faceDirection(6); f++ {
+		a := f.axis()
+		lo, hi := min[a], max[a]
+		// The faces of the neighbours looking at the region might change too.
+		if n := neighbourOffsets[f][a]; n > 0 {
+			lo -= n
+		} else {
+			hi -= n
+		}
+		for i := lo; i <= hi; i++ {
+			m.markDirty(f, i)
+		}
+	}
+	m.dirtyShapes = append(m.dirtyShapes, region{
+		min.Sub(mgl.Vec3I{1, 1, 1}),
+		max.Add(mgl.Vec3I{1, 1, 1}),
+	})
+}
+
+func (m *EditableMesh) updateShapes() bool {
+	changed := len(m.dirtyShapes) > 0
+	bounds := m.ctx.bounds
+	for _, r := range m.dirtyShapes {
+		var p mgl.Vec3I
+		for p[0] = r.min[0]; p[0] <= r.max[0]; p[0]++ {
+			for p[1] = r.min[1]; p[1] <= r.max[1]; p[1]++ {
+				for p[2] = r.min[2]; p[2] <= r.max[2]; p[2]++ {
+					if p[0] < 0 || p[1] < 0 || p[2] < 0 ||
+						p[0] >= bounds[0] || p[1] >= bounds[1] || p[2] >= bounds[2] {
+						continue
+					}
+					vox := m.ctx.chunk.At(p)
+					if !IsVoxelInvisible(vox) && shapeOf(vox) != nil {
+						m.shaped[p] = m.ctx.meshShapedVoxel(p, vox)
+					} else {
+						delete(m.shaped, p)
+					}
+				}
+			}
+		}
+	}
+	m.dirtyShapes = m.dirtyShapes[:0]
+	return changed
+}
+
+// Update rebuilds all invalidated slices and returns true if the mesh was modified.
+func (m *EditableMesh) Update() bool {
+	changed := false
+	wg := new(sync.WaitGroup)
+	for f := range m.dirty {
+		if len(m.dirty[f]) == 0 {
+			continue
+		}
+		changed = true
+		wg.Add(1)
+		go func(f faceDirection) {
+			defer wg.Done()
+			for idx := range m.dirty[f] {
+				m.slices[f][idx].set(m.ctx.meshSides(m.ctx.cullSlice(f, idx), f))
+				delete(m.dirty[f], idx)
+			}
+		}(faceDirection(f))
+	}
+	if m.updateShapes() {
+		changed = true
+		var verts []VertexF
+		for _, s := range m.shaped {
+			verts = append(verts, s...)
+		}
+		m.shapes.set(verts)
+	}
+	wg.Wait()
+
+	if changed && m.ctx.noTJunctions {
+		m.all.set(removeTJunctions(m.collect()))
+	}
+	return changed
+}
+
+// collect returns the quads of all slices and shaped voxels.
+func (m *EditableMesh) collect() []VertexF {
+	cnt := len(m.shapes.verts)
+	for _, slices := range m.slices {
+		for _, s := range slices {
+			cnt += len(s.verts)
+		}
+	}
+	verts := make([]VertexF, 0, cnt)
+	for _, slices := range m.slices {
+		for _, s := range slices {
+			verts = append(verts, s.verts...)
+		}
+	}
+	return append(verts, m.shapes.verts...)
+}
+
+// Vertices returns the quads of the mesh as of the last Update.
+func (m *EditableMesh) Vertices() []VertexF {
+	if m.ctx.noTJunctions {
+		return m.all.verts
+	}
+	return m.collect()
+}
+
+// Render updates the mesh if required and renders it. Only the slices which
+// changed are uploaded again.
+func (m *EditableMesh) Render() {
+	checkBackend(m.opt.Backend)
+	m.Update()
+	if m.ctx.noTJunctions {
+		m.all.render(m.opt)
+		return
+	}
+	for f := range m.slices {
+		for i := range m.slices[f] {
+			m.slices[f][i].render(m.opt)
+		}
+	}
+	m.shapes.render(m.opt)
+}
+
+func (m *EditableMesh) Close() {
+	for f := range m.slices {
+		for i := range m.slices[f] {
+			m.slices[f][i].close()
+		}
+	}
+	m.shapes.close()
+	m.all.close()
+}
@@ -0,0 +1,59 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_EditableMeshMatchesFullMesh(t *testing.T) {
+	for _, opt := range []Options{{}, {NoMeshing: true}} {
+		tc := stairChunk()
+		em := NewEditableMesh(tc, opt)
+		compareUnitFaces(t, em.Vertices(), meshOf(tc, opt))
+
+		edits := []struct {
+			pos mgl.Vec3I
+			vox Voxel
+		}{
+			{mgl.Vec3I{1, 1, 1}, nil},
+			{mgl.Vec3I{0, 3, 0}, testBlue},
+			{mgl.Vec3I{3, 3, 2}, nil},
+			{mgl.Vec3I{2, 2, 1}, testBlue},
+		}
+		for _, e := range edits {
+			if e.vox == nil {
+				delete(tc.voxels, e.pos)
+			} else {
+				tc.voxels[e.pos] = e.vox
+			}
+			em.Invalidate(e.pos)
+			if !em.Update() {
+				t.Errorf("Update after editing %v did not change the mesh", e.pos)
+			}
+			compareUnitFaces(t, em.Vertices(), meshOf(tc, opt))
+		}
+		if em.Update() {
+			t.Error("Update without modifications changed the mesh")
+		}
+	}
+}
+
+func Test_EditableMeshInvalidate(t *testing.T) {
+	em := NewEditableMesh(stairChunk(), Options{})
+	em.Invalidate(mgl.Vec3I{1, 1, 1})
+	for f, d := range em.dirty {
+		if len(d) != 2 {
+			t.Errorf("Expected 2 dirty slices for direction %v got %v", f, len(d))
+		}
+	}
+	em.Update()
+	em.Invalidate(mgl.Vec3I{0, 0, 0})
+	cnt := 0
+	for _, d := range em.dirty {
+		cnt += len(d)
+	}
+	if cnt != 9 {
+		t.Errorf("Expected 9 dirty slices at the corner got %v", cnt)
+	}
+}
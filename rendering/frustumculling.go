@@ -2,7 +2,6 @@ package rendering
 
 import (
 	"github.com/boombuler/voxel/mgl"
-	"github.com/go-gl-legacy/gl"
 )
 
 type plane struct {
@@ -10,18 +9,6 @@ type plane struct {
 	D float32
 }
 
-func GLProjection() mgl.Mat4 {
-	var projM [16]float32
-	gl.GetFloatv(gl.PROJECTION_MATRIX, projM[:])
-	return mgl.Mat4(projM)
-}
-
-func GLModelView() mgl.Mat4 {
-	var modM [16]float32
-	gl.GetFloatv(gl.MODELVIEW_MATRIX, modM[:])
-	return mgl.Mat4(modM)
-}
-
 func (p *plane) Assign(v mgl.Vec4) {
 	p.Vec3 = v.Vec3()
 	p.D = v.W()
@@ -34,8 +21,36 @@ func (p *plane) Normalize() {
 	p.D = p.D * mag
 }
 
+// Distance returns the signed distance of the point to the plane. It is
+// positive on the side the normal points to.
+func (p *plane) Distance(pt mgl.Vec3) float32 {
+	return p.Dot(pt) + p.D
+}
+
+// Containment is the result of testing a volume against a Frustum.
+type Containment int
+
+const (
+	Outside Containment = iota
+	Intersecting
+	Inside
+)
+
+func (c Containment) String() string {
+	switch c {
+	case Outside:
+		return "Outside"
+	case Intersecting:
+		return "Intersecting"
+	case Inside:
+		return "Inside"
+	}
+	return "Containment(?)"
+}
+
 type Frustum struct {
-	planes [6]*plane
+	planes  [6]*plane
+	corners [8]mgl.Vec3
 }
 
 const (
@@ -60,8 +75,20 @@ func NewFrustum() *Frustum {
 	return res
 }
 
-func (f *Frustum) Update() {
-	clip := GLProjection().MulMat4(GLModelView())
+// NewFrustumFromMatrix creates the frustum of a view projection matrix.
+func NewFrustumFromMatrix(viewProj mgl.Mat4) *Frustum {
+	res := NewFrustum()
+	res.SetMatrix(viewProj)
+	return res
+}
+
+func (f *Frustum) Update(projection, modelView mgl.Mat4) {
+	f.SetMatrix(projection.MulMat4(modelView))
+}
+
+// SetMatrix extracts the planes of the frustum from a view projection matrix.
+// Perspective and orthographic projections are supported.
+func (f *Frustum) SetMatrix(clip mgl.Mat4) {
 	// Die Seiten des Frustums aus der berechneten Clippingmatrix extrahieren
 	r3 := clip.Row(3)
 	f.planes[pLeft].Assign(r3.Add(clip.Row(0)))
@@ -72,6 +99,23 @@ func (f *Frustum) Update() {
 
 	f.planes[pFront].Assign(r3.Add(clip.Row(2)))
 	f.planes[pBack].Assign(r3.Sub(clip.Row(2)))
+
+	i := 0
+	for _, x := range []int{pLeft, pRight} {
+		for _, y := range []int{pBottom, pTop} {
+			for _, z := range []int{pFront, pBack} {
+				f.corners[i] = intersectPlanes(f.planes[x], f.planes[y], f.planes[z])
+				i++
+			}
+		}
+	}
+}
+
+// intersectPlanes returns the point where the three planes meet.
+func intersectPlanes(a, b, c *plane) mgl.Vec3 {
+	bc, ca, ab := b.Cross(c.Vec3), c.Cross(a.Vec3), a.Cross(b.Vec3)
+	denom := a.Dot(bc)
+	return bc.Mul(a.D).Add(ca.Mul(b.D)).Add(ab.Mul(c.D)).Mul(-1 / denom)
 }
 
 func (f *Frustum) IsPointWithin(pt mgl.Vec3) bool {
@@ -92,17 +136,56 @@ func (f *Frustum) IsSphereWithin(center mgl.Vec3, pRadius float32) bool {
 	return true
 }
 
+// IsCubeWithin checks if the axis aligned box at pt with the given size is
+// at least partly within the frustum.
 func (f *Frustum) IsCubeWithin(pt mgl.Vec3, size mgl.Vec3) bool {
-	dCenter := size.Mul(0.5)
-	center := pt.Add(dCenter)
-	pRadius := dCenter.Len()
+	return f.TestAABB(mgl.AABBFromSize(pt, size)) != Outside
+}
 
+// TestAABB checks the axis aligned box against the frustum.
+func (f *Frustum) TestAABB(box mgl.AABB) Containment {
+	min, max := box.Min, box.Max
+	res := Inside
 	for _, pl := range f.planes {
-		dPl := (pl.X()*center.X() + pl.Y()*center.Y() + pl.Z()*center.Z() + pl.D)
+		// the corners of the box farthest in front of and behind the plane
+		var near, far mgl.Vec3
+		for i := 0; i < 3; i++ {
+			if pl.Vec3[i] >= 0 {
+				far[i], near[i] = max[i], min[i]
+			} else {
+				far[i], near[i] = min[i], max[i]
+			}
+		}
+		if pl.Distance(far) < 0 {
+			return Outside
+		}
+		if pl.Distance(near) < 0 {
+			res = Intersecting
+		}
+	}
+	if res == Intersecting && f.isOutsideBox(min, max) {
+		// Boxes near the edges of the frustum may lie in front of every
+		// plane but still miss the frustum.
+		return Outside
+	}
+	return res
+}
 
-		if dPl <= -pRadius {
-			return false
+// isOutsideBox checks if all corners of the frustum are on the outer side of
+// one of the faces of the box.
+func (f *Frustum) isOutsideBox(min, max mgl.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		below, above := 0, 0
+		for _, c := range f.corners {
+			if c[i] < min[i] {
+				below++
+			} else if c[i] > max[i] {
+				above++
+			}
+		}
+		if below == len(f.corners) || above == len(f.corners) {
+			return true
 		}
 	}
-	return true
+	return false
 }
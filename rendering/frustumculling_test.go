@@ -0,0 +1,68 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type aabbTest struct {
+	name     string
+	min, max mgl.Vec3
+	exp      Containment
+}
+
+func testAABBs(t *testing.T, f *Frustum, tests []aabbTest) {
+	for _, tc := range tests {
+		if got := f.TestAABB(mgl.AABB{Min: tc.min, Max: tc.max}); got != tc.exp {
+			t.Errorf("%s: Expected %v but got %v", tc.name, tc.exp, got)
+		}
+		if within := f.IsCubeWithin(tc.min, tc.max.Sub(tc.min)); within != (tc.exp != Outside) {
+			t.Errorf("%s: IsCubeWithin returned %v", tc.name, within)
+		}
+	}
+}
+
+func Test_FrustumPerspective(t *testing.T) {
+	f := NewFrustumFromMatrix(mgl.Perspective(mgl.Degree(90).ToRadian(), 1, 1, 100))
+	testAABBs(t, f, []aabbTest{
+		{"center", mgl.Vec3{-1, -1, -11}, mgl.Vec3{1, 1, -9}, Inside},
+		{"left edge", mgl.Vec3{-12, -1, -11}, mgl.Vec3{-8, 1, -9}, Intersecting},
+		{"near plane", mgl.Vec3{-0.1, -0.1, -2}, mgl.Vec3{0.1, 0.1, 0}, Intersecting},
+		{"enclosing", mgl.Vec3{-200, -200, -200}, mgl.Vec3{200, 200, 200}, Intersecting},
+		{"behind", mgl.Vec3{-1, -1, 1}, mgl.Vec3{1, 1, 3}, Outside},
+		{"too far", mgl.Vec3{-1, -1, -120}, mgl.Vec3{1, 1, -101}, Outside},
+		{"right", mgl.Vec3{12, -1, -11}, mgl.Vec3{14, 1, -9}, Outside},
+		// in front of the right and far plane but beyond their edge
+		{"far corner", mgl.Vec3{101, -1, -150}, mgl.Vec3{150, 1, -98}, Outside},
+	})
+}
+
+func Test_FrustumOrthographic(t *testing.T) {
+	f := NewFrustumFromMatrix(mgl.Ortho(-10, 10, -5, 5, 1, 50))
+	testAABBs(t, f, []aabbTest{
+		{"center", mgl.Vec3{-9, -4, -40}, mgl.Vec3{9, 4, -2}, Inside},
+		{"top edge", mgl.Vec3{-1, 4, -10}, mgl.Vec3{1, 6, -8}, Intersecting},
+		{"above", mgl.Vec3{-1, 5.5, -10}, mgl.Vec3{1, 6, -8}, Outside},
+		{"behind near", mgl.Vec3{-1, -1, -0.5}, mgl.Vec3{1, 1, 5}, Outside},
+		{"far corner", mgl.Vec3{10.5, -1, -60}, mgl.Vec3{20, 1, -40}, Outside},
+	})
+}
+
+func Test_FrustumView(t *testing.T) {
+	view := mgl.Identity().Translate(0, 0, -50)
+	f := NewFrustumFromMatrix(mgl.Perspective(mgl.Degree(60).ToRadian(), 1.5, 1, 100).MulMat4(view))
+	testAABBs(t, f, []aabbTest{
+		{"origin", mgl.Vec3{-1, -1, -1}, mgl.Vec3{1, 1, 1}, Inside},
+		{"behind camera", mgl.Vec3{-1, -1, 51}, mgl.Vec3{1, 1, 53}, Outside},
+	})
+
+	f2 := NewFrustum()
+	f2.Update(mgl.Perspective(mgl.Degree(60).ToRadian(), 1.5, 1, 100), view)
+	if *f2.planes[pLeft] != *f.planes[pLeft] || f2.corners != f.corners {
+		t.Error("Expected Update to match NewFrustumFromMatrix")
+	}
+	if !f.IsPointWithin(mgl.Vec3{}) || f.IsPointWithin(mgl.Vec3{0, 0, 60}) {
+		t.Error("IsPointWithin returned wrong results")
+	}
+}
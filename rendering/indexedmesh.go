@@ -0,0 +1,41 @@
+package rendering
+
+// CreateIndexedMeshFromChunk works like CreateMeshFromChunk but returns an
+// indexed triangle mesh instead of a list of quads.
+func CreateIndexedMeshFromChunk(c Chunk, o Options) ([]VertexF, []uint32) {
+	quads, _ := CreateMeshFromChunk(c, o)
+	return IndexQuads(quads)
+}
+
+// IndexQuads converts a quad list as returned by CreateMeshFromChunk to an
+// indexed triangle list. Equal vertices are welded together and every quad is
+// split into two triangles keeping the counter-clockwise winding of the quad.
+// Degenerated triangles are dropped.
+func IndexQuads(quads []VertexF) (verts []VertexF, indices []uint32) {
+	verts = make([]VertexF, 0, len(quads)/2)
+	indices = make([]uint32, 0, (len(quads)/4)*6)
+	known := make(map[VertexF]uint32, len(quads)/2)
+
+	index := func(v VertexF) uint32 {
+		idx, ok := known[v]
+		if !ok {
+			idx = uint32(len(verts))
+			verts = append(verts, v)
+			known[v] = idx
+		}
+		return idx
+	}
+	addTriangle := func(a, b, c VertexF) {
+		if a.Pos.Equals(b.Pos) || b.Pos.Equals(c.Pos) || c.Pos.Equals(a.Pos) {
+			return
+		}
+		indices = append(indices, index(a), index(b), index(c))
+	}
+
+	for i := 0; i+3 < len(quads); i += 4 {
+		q := quads[i : i+4]
+		addTriangle(q[0], q[1], q[2])
+		addTriangle(q[0], q[2], q[3])
+	}
+	return verts, indices
+}
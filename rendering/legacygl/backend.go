@@ -0,0 +1,150 @@
+// Package legacygl implements a rendering.Backend using the fixed function
+// pipeline of OpenGL.
+package legacygl
+
+import (
+	"unsafe"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+	"github.com/go-gl-legacy/gl"
+)
+
+var (
+	vertexF_Size = int(unsafe.Sizeof(rendering.VertexF{}))
+	vertexP_Size = int(unsafe.Sizeof(rendering.VertexP{}))
+)
+
+type mesh struct {
+	buf    gl.Buffer
+	length int
+
+	// only set for packed meshes
+	prog        *packedProgram
+	palette     gl.Texture
+	paletteSize int
+}
+
+// Backend draws meshes using vertex buffers and the matrix stack of OpenGL.
+type Backend struct {
+	meshes map[rendering.MeshHandle]*mesh
+	next   rendering.MeshHandle
+}
+
+func New() *Backend {
+	return &Backend{
+		meshes: make(map[rendering.MeshHandle]*mesh),
+	}
+}
+
+// Init sets up the state of the current OpenGL context.
+func (b *Backend) Init(width, height int) {
+	gl.Init()
+	gl.Enable(gl.DEPTH_TEST)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.ClearDepth(1)
+	gl.DepthFunc(gl.LEQUAL)
+
+	gl.Viewport(0, 0, width, height)
+	b.SetProjection(mgl.Identity())
+	b.SetModelView(mgl.Identity())
+}
+
+func (b *Backend) add(m *mesh) rendering.MeshHandle {
+	b.next++
+	b.meshes[b.next] = m
+	return b.next
+}
+
+func (b *Backend) CreateMesh(quads []rendering.VertexF) rendering.MeshHandle {
+	m := &mesh{
+		buf:    gl.GenBuffer(),
+		length: len(quads),
+	}
+	m.buf.Bind(gl.ARRAY_BUFFER)
+	defer m.buf.Unbind(gl.ARRAY_BUFFER)
+	gl.BufferData(gl.ARRAY_BUFFER, m.length*vertexF_Size, quads, gl.STATIC_DRAW)
+	return b.add(m)
+}
+
+func (b *Backend) UploadMesh(h rendering.MeshHandle, quads []rendering.VertexF) {
+	m := b.meshes[h]
+	m.length = len(quads)
+	m.buf.Bind(gl.ARRAY_BUFFER)
+	defer m.buf.Unbind(gl.ARRAY_BUFFER)
+	gl.BufferData(gl.ARRAY_BUFFER, m.length*vertexF_Size, quads, gl.DYNAMIC_DRAW)
+}
+
+func (b *Backend) DeleteMesh(h rendering.MeshHandle) {
+	m, ok := b.meshes[h]
+	if !ok {
+		return
+	}
+	m.buf.Delete()
+	if m.prog != nil {
+		m.palette.Delete()
+	}
+	delete(b.meshes, h)
+}
+
+func (b *Backend) DrawMesh(h rendering.MeshHandle) {
+	m := b.meshes[h]
+	if m.prog != nil {
+		m.drawPacked()
+		return
+	}
+	m.buf.Bind(gl.ARRAY_BUFFER)
+	gl.EnableClientState(gl.VERTEX_ARRAY)
+	defer gl.DisableClientState(gl.VERTEX_ARRAY)
+
+	gl.InterleavedArrays(gl.C4F_N3F_V3F, vertexF_Size, nil)
+	gl.DrawArrays(gl.QUADS, 0, m.length)
+}
+
+func (b *Backend) DrawQuads(quads []rendering.VertexF) {
+	gl.Begin(gl.QUADS)
+	defer gl.End()
+	for _, v := range quads {
+		gl.Normal3f(v.Norm.X(), v.Norm.Y(), v.Norm.Z())
+		gl.Color4f(v.Color.Red, v.Color.Green, v.Color.Blue, v.Color.Alpha)
+		gl.Vertex3f(v.Pos.X(), v.Pos.Y(), v.Pos.Z())
+	}
+}
+
+func (b *Backend) Clear() {
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+func (b *Backend) SetProjection(m mgl.Mat4) {
+	gl.MatrixMode(gl.PROJECTION)
+	gl.LoadMatrixf((*[16]float32)(&m))
+	gl.MatrixMode(gl.MODELVIEW)
+}
+
+func (b *Backend) Projection() mgl.Mat4 {
+	var projM [16]float32
+	gl.GetFloatv(gl.PROJECTION_MATRIX, projM[:])
+	return mgl.Mat4(projM)
+}
+
+func (b *Backend) SetModelView(m mgl.Mat4) {
+	gl.MatrixMode(gl.MODELVIEW)
+	gl.LoadMatrixf((*[16]float32)(&m))
+}
+
+func (b *Backend) ModelView() mgl.Mat4 {
+	var modM [16]float32
+	gl.GetFloatv(gl.MODELVIEW_MATRIX, modM[:])
+	return mgl.Mat4(modM)
+}
+
+func (b *Backend) PushTransform(m mgl.Mat4) {
+	gl.PushMatrix()
+	gl.MultMatrixf((*[16]float32)(&m))
+}
+
+func (b *Backend) PopTransform() {
+	gl.PopMatrix()
+}
+
+var _ rendering.PackedBackend = (*Backend)(nil)
@@ -0,0 +1,152 @@
+package legacygl
+
+import (
+	"fmt"
+
+	"github.com/boombuler/voxel/rendering"
+	"github.com/go-gl-legacy/gl"
+)
+
+const packedVertexShader = `#version 120
+attribute vec3 position;
+attribute float color;
+attribute float ao;
+uniform float paletteSize;
+varying float vColor;
+varying float vLight;
+
+void main() {
+	vColor = (color + 0.5) / paletteSize;
+	vLight = 0.4 + 0.6 * (ao / 3.0);
+	gl_Position = gl_ModelViewProjectionMatrix * vec4(position, 1.0);
+}
+`
+
+const packedFragmentShader = `#version 120
+uniform sampler1D palette;
+varying float vColor;
+varying float vLight;
+
+void main() {
+	vec4 c = texture1D(palette, vColor);
+	gl_FragColor = vec4(c.rgb * vLight, c.a);
+}
+`
+
+type packedProgram struct {
+	gl.Program
+	position    gl.AttribLocation
+	color       gl.AttribLocation
+	ao          gl.AttribLocation
+	palette     gl.UniformLocation
+	paletteSize gl.UniformLocation
+}
+
+// the shader program is shared by all packed meshes and created on first use.
+var sharedPackedProgram *packedProgram
+
+func compileShader(typ gl.GLenum, src string) (gl.Shader, error) {
+	s := gl.CreateShader(typ)
+	s.Source(src)
+	s.Compile()
+	if s.Get(gl.COMPILE_STATUS) == gl.FALSE {
+		defer s.Delete()
+		return s, fmt.Errorf("failed to compile shader: %v", s.GetInfoLog())
+	}
+	return s, nil
+}
+
+func getPackedProgram() (*packedProgram, error) {
+	if sharedPackedProgram != nil {
+		return sharedPackedProgram, nil
+	}
+	vs, err := compileShader(gl.VERTEX_SHADER, packedVertexShader)
+	if err != nil {
+		return nil, err
+	}
+	defer vs.Delete()
+	fs, err := compileShader(gl.FRAGMENT_SHADER, packedFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Delete()
+
+	p := gl.CreateProgram()
+	p.AttachShader(vs)
+	p.AttachShader(fs)
+	p.Link()
+	if p.Get(gl.LINK_STATUS) == gl.FALSE {
+		defer p.Delete()
+		return nil, fmt.Errorf("failed to link shader program: %v", p.GetInfoLog())
+	}
+
+	sharedPackedProgram = &packedProgram{
+		Program:     p,
+		position:    p.GetAttribLocation("position"),
+		color:       p.GetAttribLocation("color"),
+		ao:          p.GetAttribLocation("ao"),
+		palette:     p.GetUniformLocation("palette"),
+		paletteSize: p.GetUniformLocation("paletteSize"),
+	}
+	return sharedPackedProgram, nil
+}
+
+// CreatePackedMesh uploads the packed vertices and stores the palette in a
+// texture which is looked up by a shader.
+func (b *Backend) CreatePackedMesh(verts []rendering.VertexP, palette []rendering.Color) (rendering.MeshHandle, error) {
+	prog, err := getPackedProgram()
+	if err != nil {
+		return 0, err
+	}
+	m := &mesh{
+		prog:        prog,
+		paletteSize: len(palette),
+		length:      len(verts),
+	}
+
+	m.buf = gl.GenBuffer()
+	m.buf.Bind(gl.ARRAY_BUFFER)
+	defer m.buf.Unbind(gl.ARRAY_BUFFER)
+	gl.BufferData(gl.ARRAY_BUFFER, m.length*vertexP_Size, verts, gl.STATIC_DRAW)
+
+	m.palette = gl.GenTexture()
+	m.palette.Bind(gl.TEXTURE_1D)
+	defer m.palette.Unbind(gl.TEXTURE_1D)
+	gl.TexParameteri(gl.TEXTURE_1D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_1D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_1D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexImage1D(gl.TEXTURE_1D, 0, gl.RGBA, len(palette), 0, gl.RGBA, gl.FLOAT, palette)
+
+	return b.add(m), nil
+}
+
+func (m *mesh) drawPacked() {
+	m.prog.Use()
+	defer gl.ProgramUnuse()
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	m.palette.Bind(gl.TEXTURE_1D)
+	defer m.palette.Unbind(gl.TEXTURE_1D)
+	m.prog.palette.Uniform1i(0)
+	m.prog.paletteSize.Uniform1f(float32(m.paletteSize))
+
+	m.buf.Bind(gl.ARRAY_BUFFER)
+	defer m.buf.Unbind(gl.ARRAY_BUFFER)
+	attribs := []struct {
+		loc    gl.AttribLocation
+		size   uint
+		typ    gl.GLenum
+		offset uintptr
+	}{
+		{m.prog.position, 3, gl.UNSIGNED_BYTE, 0},
+		{m.prog.color, 1, gl.UNSIGNED_SHORT, 4},
+		{m.prog.ao, 1, gl.UNSIGNED_BYTE, 6},
+	}
+	for _, a := range attribs {
+		a.loc.AttribPointer(a.size, a.typ, false, vertexP_Size, a.offset)
+		a.loc.EnableArray()
+		defer a.loc.DisableArray()
+	}
+
+	gl.DrawArrays(gl.QUADS, 0, m.length)
+}
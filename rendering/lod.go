@@ -0,0 +1,316 @@
+package rendering
+
+import (
+	"context"
+	"image/color"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// DownsampleMode defines how the color of a downsampled voxel is calculated.
+type DownsampleMode int
+
+const (
+	// MajorityColor uses the most frequent color of the merged voxels.
+	MajorityColor DownsampleMode = iota
+	// AverageColor uses the average color of the merged voxels.
+	AverageColor
+)
+
+type lodVoxel color.RGBA64
+
+func (v lodVoxel) Color() color.Color {
+	return color.RGBA64(v)
+}
+
+type lodChunk struct {
+	size   mgl.Vec3I
+	voxels []Voxel
+}
+
+func (lc *lodChunk) idx(pos mgl.Vec3I) int {
+	return (((pos.Z() * lc.size.Y()) + pos.Y()) * lc.size.X()) + pos.X()
+}
+
+func (lc *lodChunk) Size() mgl.Vec3I {
+	return lc.size
+}
+
+func (lc *lodChunk) At(pos mgl.Vec3I) Voxel {
+	return lc.voxels[lc.idx(pos)]
+}
+
+func (lc *lodChunk) ForeachVoxel(fn func(pos mgl.Vec3I, vox Voxel)) {
+	for z := 0; z < lc.size.Z(); z++ {
+		for y := 0; y < lc.size.Y(); y++ {
+			for x := 0; x < lc.size.X(); x++ {
+				p := mgl.Vec3I{x, y, z}
+				if vox := lc.voxels[lc.idx(p)]; vox != nil {
+					fn(p, vox)
+				}
+			}
+		}
+	}
+}
+
+func mergeVoxels(voxels []Voxel, mode DownsampleMode) Voxel {
+	if len(voxels) == 0 {
+		return nil
+	}
+	switch mode {
+	case AverageColor:
+		var r, g, b, a uint64
+		for _, v := range voxels {
+			vr, vg, vb, va := v.Color().RGBA()
+			r, g, b, a = r+uint64(vr), g+uint64(vg), b+uint64(vb), a+uint64(va)
+		}
+		n := uint64(len(voxels))
+		return lodVoxel{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)}
+	default:
+		counts := make(map[color.RGBA64]int)
+		var result Voxel
+		best := 0
+		for _, v := range voxels {
+			r, g, b, a := v.Color().RGBA()
+			c := color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+			counts[c]++
+			if counts[c] > best {
+				best = counts[c]
+				result = v
+			}
+		}
+		return result
+	}
+}
+
+// Downsample merges blocks of factor³ voxels into a single voxel. A merged
+// voxel is visible if any of the voxels of the block is visible. The last
+// blocks are smaller if the size is not divisible by the factor, so the
+// downsampled chunk scaled by the factor may exceed the original chunk.
+func Downsample(c Chunk, factor int, mode DownsampleMode) Chunk {
+	size := c.Size()
+	res := &lodChunk{
+		size: mgl.Vec3I{
+			(size.X() + factor - 1) / factor,
+			(size.Y() + factor - 1) / factor,
+			(size.Z() + factor - 1) / factor,
+		},
+	}
+	res.voxels = make([]Voxel, res.size.X()*res.size.Y()*res.size.Z())
+
+	block := make([]Voxel, 0, factor*factor*factor)
+	for z := 0; z < res.size.Z(); z++ {
+		for y := 0; y < res.size.Y(); y++ {
+			for x := 0; x < res.size.X(); x++ {
+				block = block[:0]
+				start := mgl.Vec3I{x, y, z}.Mul(factor)
+				for dz := 0; dz < factor && start.Z()+dz < size.Z(); dz++ {
+					for dy := 0; dy < factor && start.Y()+dy < size.Y(); dy++ {
+						for dx := 0; dx < factor && start.X()+dx < size.X(); dx++ {
+							vox := c.At(start.Add(mgl.Vec3I{dx, dy, dz}))
+							if !IsVoxelInvisible(vox) {
+								block = append(block, vox)
+							}
+						}
+					}
+				}
+				res.voxels[res.idx(mgl.Vec3I{x, y, z})] = mergeVoxels(block, mode)
+			}
+		}
+	}
+	return res
+}
+
+// clampMesh moves the vertices outside of the bounds onto the bounds.
+func clampMesh(mesh []VertexF, bounds mgl.Vec3) {
+	for i := range mesh {
+		for j := 0; j < 3; j++ {
+			if mesh[i].Pos[j] > bounds[j] {
+				mesh[i].Pos[j] = bounds[j]
+			}
+		}
+	}
+}
+
+// appendSkirts adds quads hanging from the edges of the faces along the chunk
+// borders into the chunk. They hide the gaps between neighbouring chunks which
+// are rendered with a different level of detail.
+func appendSkirts(mesh []VertexF, bounds mgl.Vec3, depth float32) []VertexF {
+	cnt := len(mesh)
+	for i := 0; i+3 < cnt; i += 4 {
+		q := mesh[i : i+4]
+		normalAxis := -1
+		for j := 0; j < 3; j++ {
+			if q[0].Norm[j] != 0 {
+				if normalAxis >= 0 {
+					// not axis aligned
+					normalAxis = -1
+					break
+				}
+				normalAxis = j
+			}
+		}
+		if normalAxis < 0 {
+			continue
+		}
+		lo, hi := q[0].Pos, q[0].Pos
+		for _, v := range q[1:] {
+			for j := 0; j < 3; j++ {
+				if v.Pos[j] < lo[j] {
+					lo[j] = v.Pos[j]
+				}
+				if v.Pos[j] > hi[j] {
+					hi[j] = v.Pos[j]
+				}
+			}
+		}
+		drop := q[0].Norm.Mul(depth)
+		for axis := 0; axis < 3; axis++ {
+			if axis == normalAxis {
+				continue
+			}
+			other := 3 - normalAxis - axis
+			var along mgl.Vec3
+			along[other] = hi[other] - lo[other]
+			for _, border := range []float32{0, bounds[axis]} {
+				var n mgl.Vec3
+				switch border {
+				case lo[axis]:
+					n[axis] = -1
+				case hi[axis]:
+					n[axis] = 1
+				default:
+					continue
+				}
+				start := lo.Sub(drop)
+				start[axis] = border
+				mesh = appendQuad(mesh, q[0].Color, n, start, along, drop)
+			}
+		}
+	}
+	return mesh
+}
+
+// LODRenderer is implemented by renderers supporting multiple levels of detail.
+type LODRenderer interface {
+	Renderer
+	// RenderLOD renders the level of detail suitable for the given projected
+	// size of the object in pixels.
+	RenderLOD(screenSize float32)
+}
+
+type LODOptions struct {
+	// Levels is the number of downsampled levels. Each level halves the resolution.
+	Levels int
+	Mode   DownsampleMode
+	// Skirts adds skirts to the borders of the downsampled levels.
+	Skirts bool
+	// MaxVoxelPixels is the maximum projected size of a voxel before a finer
+	// level is used. Defaults to 1.
+	MaxVoxelPixels float32
+}
+
+// LODMesh renders a chunk with multiple levels of detail.
+type LODMesh struct {
+	backend        Backend
+	levels         []Renderer
+	voxelCount     float32
+	maxVoxelPixels float32
+}
+
+// lodLevel is a downsampled level of a LODMesh.
+type lodLevel struct {
+	chunk Chunk
+	mesh  []VertexF
+}
+
+// createLODLevels meshes the downsampled levels of the chunk. The clip planes
+// are applied before the chunk is downsampled. The last cells of sizes which
+// are not divisible by the factor of a level are clamped to the chunk.
+func createLODLevels(ctx context.Context, c Chunk, opt Options, lod LODOptions) ([]lodLevel, error) {
+	clipped := clipChunk(c, opt.ClipPlanes)
+	opt.ClipPlanes = nil
+	levels := make([]lodLevel, 0, lod.Levels)
+	for i := 1; i <= lod.Levels; i++ {
+		factor := 1 << uint(i)
+		lc := Downsample(clipped, factor, lod.Mode)
+		mesh, _, err := CreateMeshFromChunkContext(ctx, lc, opt)
+		if err != nil {
+			return nil, err
+		}
+		bounds := c.Size().Vec3().Mul(1 / float32(factor))
+		clampMesh(mesh, bounds)
+		if lod.Skirts {
+			mesh = appendSkirts(mesh, bounds, 1)
+		}
+		levels = append(levels, lodLevel{lc, mesh})
+	}
+	return levels, nil
+}
+
+func NewLODMesh(c Chunk, opt Options, lod LODOptions) *LODMesh {
+	checkBackend(opt.Backend)
+	mesh, _ := CreateMeshFromChunk(c, opt)
+	levels, _ := createLODLevels(context.Background(), c, opt, lod)
+	return newLODMeshFromLevels(c, mesh, levels, opt, lod)
+}
+
+// newLODMeshFromLevels uploads the mesh of the chunk and its downsampled
+// levels.
+func newLODMeshFromLevels(c Chunk, mesh []VertexF, levels []lodLevel, opt Options, lod LODOptions) *LODMesh {
+	size := c.Size()
+	res := &LODMesh{
+		backend:        opt.Backend,
+		levels:         make([]Renderer, 0, len(levels)+1),
+		voxelCount:     float32(size.X()),
+		maxVoxelPixels: lod.MaxVoxelPixels,
+	}
+	for _, s := range []int{size.Y(), size.Z()} {
+		if float32(s) > res.voxelCount {
+			res.voxelCount = float32(s)
+		}
+	}
+	if res.maxVoxelPixels <= 0 {
+		res.maxVoxelPixels = 1
+	}
+	res.levels = append(res.levels, newRendererFromMesh(c, mesh, opt))
+	for _, l := range levels {
+		res.levels = append(res.levels, newRendererFromMesh(l.chunk, l.mesh, opt))
+	}
+	return res
+}
+
+// Level returns the level of detail used for the given projected size in pixels.
+func (m *LODMesh) Level(screenSize float32) int {
+	level := 0
+	voxelPixels := screenSize / m.voxelCount
+	for level+1 < len(m.levels) && voxelPixels*float32(int(2)<<uint(level)) <= m.maxVoxelPixels {
+		level++
+	}
+	return level
+}
+
+func (m *LODMesh) RenderLOD(screenSize float32) {
+	level := m.Level(screenSize)
+	if level == 0 {
+		m.levels[0].Render()
+		return
+	}
+	scale := float32(int(1) << uint(level))
+	m.backend.PushTransform(mgl.Identity().Scale(scale, scale, scale))
+	defer m.backend.PopTransform()
+	m.levels[level].Render()
+}
+
+// Render renders the finest level of detail.
+func (m *LODMesh) Render() {
+	m.levels[0].Render()
+}
+
+func (m *LODMesh) Close() {
+	for _, l := range m.levels {
+		if rc, ok := l.(RenderCloser); ok {
+			rc.Close()
+		}
+	}
+}
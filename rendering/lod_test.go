@@ -0,0 +1,127 @@
+package rendering
+
+import (
+	"context"
+	"image/color"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_Downsample(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{3, 2, 2})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{0, 1, 0}] = testBlue
+	tc.voxels[mgl.Vec3I{2, 1, 1}] = testBlue
+
+	maj := Downsample(tc, 2, MajorityColor)
+	if s := maj.Size(); !s.Equals(mgl.Vec3I{2, 1, 1}) {
+		t.Fatalf("Invalid size of downsampled chunk: %v", s)
+	}
+	if v := maj.At(mgl.Vec3I{0, 0, 0}); v != testRed {
+		t.Errorf("Majority voxel should be red got %v", v)
+	}
+	if v := maj.At(mgl.Vec3I{1, 0, 0}); v != testBlue {
+		t.Errorf("Partial block should keep its voxel got %v", v)
+	}
+
+	avg := Downsample(tc, 2, AverageColor)
+	r, g, b, a := avg.At(mgl.Vec3I{0, 0, 0}).Color().RGBA()
+	er, eg, eb, ea := color.RGBA64{0xAAAA, 0, 0x5555, 0xFFFF}.RGBA()
+	if r != er || g != eg || b != eb || a != ea {
+		t.Errorf("Invalid average color %v %v %v %v", r, g, b, a)
+	}
+
+	empty := Downsample(newTestChunk(mgl.Vec3I{4, 4, 4}), 4, MajorityColor)
+	if v := empty.At(mgl.Vec3I{0, 0, 0}); v != nil {
+		t.Errorf("Empty block should stay empty got %v", v)
+	}
+}
+
+func Test_LODMeshLevel(t *testing.T) {
+	m := &LODMesh{
+		levels:         make([]Renderer, 4),
+		voxelCount:     64,
+		maxVoxelPixels: 1,
+	}
+	tests := []struct {
+		size  float32
+		level int
+	}{
+		{1000, 0},
+		{64, 0},
+		{32, 1},
+		{20, 1},
+		{16, 2},
+		{8, 3},
+		{1, 3},
+	}
+	for _, test := range tests {
+		if l := m.Level(test.size); l != test.level {
+			t.Errorf("Level for size %v is %v expected %v", test.size, l, test.level)
+		}
+	}
+}
+
+func Test_LODLevelsClipped(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{8, 8, 8}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	levels, err := createLODLevels(context.Background(), tc, Options{ClipPlanes: []ClipPlane{ClipAbove(3.5)}}, LODOptions{Levels: 1})
+	if err != nil || len(levels) != 1 {
+		t.Fatalf("Expected one level got %v (%v)", len(levels), err)
+	}
+	for _, v := range levels[0].mesh {
+		if v.Pos.Y() > 2 {
+			t.Fatalf("Downsampled level should be clipped at height 2 got vertex %v", v.Pos)
+		}
+	}
+}
+
+func Test_AppendSkirts(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{2, 1, 2})
+	for x := 0; x < 2; x++ {
+		for z := 0; z < 2; z++ {
+			tc.voxels[mgl.Vec3I{x, 0, z}] = testRed
+		}
+	}
+	mesh := meshOf(tc, Options{})
+	bounds := tc.Size().Vec3()
+	withSkirts := appendSkirts(mesh, bounds, 1)
+	// Each of the 6 quads touches four borders
+	if len(withSkirts)-len(mesh) != 6*4*4 {
+		t.Fatalf("Expected 24 skirt quads got %v vertices", len(withSkirts)-len(mesh))
+	}
+	for i := len(mesh); i < len(withSkirts); i += 4 {
+		q := withSkirts[i : i+4]
+		for j := 0; j < 3; j++ {
+			if q[0].Norm[j] == 0 {
+				continue
+			}
+			border := float32(0)
+			if q[0].Norm[j] > 0 {
+				border = bounds[j]
+			}
+			for _, v := range q {
+				if v.Pos[j] != border {
+					t.Errorf("Skirt vertex %v is not on the border %v of axis %v", v.Pos, border, j)
+				}
+			}
+		}
+	}
+}
+
+func Test_LODLevelsClamped(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{3, 3, 3}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	levels, _ := createLODLevels(context.Background(), tc, Options{}, LODOptions{Levels: 1, Skirts: true})
+	for _, v := range levels[0].mesh {
+		for j := 0; j < 3; j++ {
+			if v.Pos[j] < -1 || v.Pos[j] > 1.5 {
+				t.Fatalf("Vertex %v of the downsampled level exceeds the chunk", v.Pos)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package rendering
+
+import (
+	"image/color"
+)
+
+// MergeKeyFunc returns the key of a voxel used to decide whether neighbouring
+// faces can be merged to a single quad. Two voxels are merged if their keys
+// are equal, even if their colors differ: a merged quad has the color of one
+// of its voxels. The returned keys have to be comparable, meshing panics
+// otherwise.
+type MergeKeyFunc func(v Voxel) interface{}
+
+// MergeKeyer is implemented by voxels which provide their own merge key. The
+// key has to be comparable. Faces of voxels with the same key but different
+// colors are merged to quads with the color of one of the voxels.
+type MergeKeyer interface {
+	Voxel
+	MergeKey() interface{}
+}
+
+// MaterialVoxel is implemented by voxels which have a material.
+type MaterialVoxel interface {
+	Voxel
+	Material() interface{}
+}
+
+// MergeByColor merges voxels with the same color.
+func MergeByColor(v Voxel) interface{} {
+	c := v.Color()
+	if c == nil {
+		return nil
+	}
+	r, g, b, a := c.RGBA()
+	return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+}
+
+// MergeByMaterial merges voxels with the same material, the merged quads lose
+// the colors of all but one voxel. Voxels without a material are merged by
+// their color. The materials have to be comparable.
+func MergeByMaterial(v Voxel) interface{} {
+	if mv, ok := v.(MaterialVoxel); ok {
+		return mv.Material()
+	}
+	return MergeByColor(v)
+}
+
+// MergeByIdentity only merges voxels which are equal by interface comparison.
+func MergeByIdentity(v Voxel) interface{} {
+	return v
+}
+
+// DefaultMergeKey uses the MergeKey of voxels implementing MergeKeyer and the
+// color of all other voxels.
+func DefaultMergeKey(v Voxel) interface{} {
+	if mk, ok := v.(MergeKeyer); ok {
+		return mk.MergeKey()
+	}
+	return MergeByColor(v)
+}
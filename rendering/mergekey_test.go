@@ -0,0 +1,115 @@
+package rendering
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type ptrVoxel struct {
+	c color.RGBA
+}
+
+func (pv *ptrVoxel) Color() color.Color {
+	return pv.c
+}
+
+type keyedVoxel struct {
+	testVoxel
+	key string
+}
+
+func (kv keyedVoxel) MergeKey() interface{} {
+	return kv.key
+}
+
+func filledChunk(size mgl.Vec3I, fn func(p mgl.Vec3I) Voxel) *testChunk {
+	tc := newTestChunk(size)
+	for x := 0; x < size.X(); x++ {
+		for y := 0; y < size.Y(); y++ {
+			for z := 0; z < size.Z(); z++ {
+				p := mgl.Vec3I{x, y, z}
+				tc.voxels[p] = fn(p)
+			}
+		}
+	}
+	return tc
+}
+
+func Test_MergeEqualColors(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{3, 3, 3}, func(p mgl.Vec3I) Voxel {
+		return &ptrVoxel{color.RGBA{255, 0, 0, 255}}
+	})
+	if q := len(meshOf(tc, Options{})) / 4; q != 6 {
+		t.Errorf("Distinct voxels with equal colors should be merged to 6 quads got %v", q)
+	}
+
+	if k1, k2 := MergeByIdentity(tc.At(mgl.Vec3I{0, 0, 0})), MergeByIdentity(tc.At(mgl.Vec3I{1, 0, 0})); k1 == k2 {
+		t.Error("Distinct pointer voxels should have different identity keys")
+	}
+}
+
+func Test_MergeKeyer(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{2, 1, 1}, func(p mgl.Vec3I) Voxel {
+		if p.X() == 0 {
+			return keyedVoxel{testRed, "stone"}
+		}
+		return keyedVoxel{testBlue, "stone"}
+	})
+	if q := len(meshOf(tc, Options{})) / 4; q != 6 {
+		t.Errorf("Voxels with equal merge keys should be merged to 6 quads got %v", q)
+	}
+
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = keyedVoxel{testRed, "wood"}
+	if q := len(meshOf(tc, Options{})) / 4; q != 10 {
+		t.Errorf("Voxels with different merge keys should not be merged got %v quads", q)
+	}
+}
+
+func Test_MergeByMaterial(t *testing.T) {
+	if MergeByMaterial(testRed) != MergeByColor(testVoxel{255, 0, 0, 255}) {
+		t.Error("Voxels without material should be merged by color")
+	}
+}
+
+func Test_CullingTranslucentMergeKey(t *testing.T) {
+	glass := testVoxel{0, 255, 0, 128}
+	tc := filledChunk(mgl.Vec3I{2, 2, 2}, func(p mgl.Vec3I) Voxel {
+		return glass
+	})
+	if q := len(meshOf(tc, Options{NoMeshing: true})) / 4; q != 6*4 {
+		t.Errorf("Faces between equal translucent voxels should be hidden got %v quads", q)
+	}
+
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testVoxel{0, 0, 255, 128}
+	faces := unitFaces(meshOf(tc, Options{NoMeshing: true}))
+	if _, ok := faces[unitFace{mgl.Vec3I{1, 0, 0}, mgl.Vec3I{-1, 0, 0}}]; !ok {
+		t.Error("Faces between different translucent voxels should be visible")
+	}
+}
+
+func Test_CullingOpaqueNextToTranslucent(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{2, 1, 1}, func(p mgl.Vec3I) Voxel {
+		if p.X() == 0 {
+			return keyedVoxel{testRed, "glass"}
+		}
+		return keyedVoxel{testVoxel{255, 0, 0, 128}, "glass"}
+	})
+	faces := unitFaces(meshOf(tc, Options{NoMeshing: true}))
+	if _, ok := faces[unitFace{mgl.Vec3I{1, 0, 0}, mgl.Vec3I{1, 0, 0}}]; !ok {
+		t.Error("Face of an opaque voxel next to a translucent voxel with the same merge key should be visible")
+	}
+	if _, ok := faces[unitFace{mgl.Vec3I{1, 0, 0}, mgl.Vec3I{-1, 0, 0}}]; ok {
+		t.Error("Face of a translucent voxel next to an opaque voxel should be hidden")
+	}
+}
+
+func Test_MergeKeyOption(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{2, 1, 1}, func(p mgl.Vec3I) Voxel {
+		return &ptrVoxel{color.RGBA{255, 0, 0, 255}}
+	})
+	if q := len(meshOf(tc, Options{MergeKey: MergeByIdentity})) / 4; q != 10 {
+		t.Errorf("Identity merge key should not merge distinct voxels got %v quads", q)
+	}
+}
@@ -1,35 +1,29 @@
 package rendering
 
-import "github.com/go-gl-legacy/gl"
-
 type CubeMesh struct {
-	buf    gl.Buffer
-	length int
+	backend Backend
+	handle  MeshHandle
 }
 
-func NewCubeMesh(verts []VertexF) *CubeMesh {
-	r := new(CubeMesh)
-	r.length = len(verts)
-
-	r.buf = gl.GenBuffer()
-	r.buf.Bind(gl.ARRAY_BUFFER)
-	defer r.buf.Unbind(gl.ARRAY_BUFFER)
-
-	cnt := r.length * vertexF_Size
-	gl.BufferData(gl.ARRAY_BUFFER, cnt, verts, gl.STATIC_DRAW)
+// NewCubeMesh uploads the vertices using the backend. It panics if the backend
+// is nil.
+func NewCubeMesh(b Backend, verts []VertexF) *CubeMesh {
+	checkBackend(b)
+	return &CubeMesh{
+		backend: b,
+		handle:  b.CreateMesh(verts),
+	}
+}
 
-	return r
+// Update replaces the vertices of the mesh.
+func (v *CubeMesh) Update(verts []VertexF) {
+	v.backend.UploadMesh(v.handle, verts)
 }
 
 func (v *CubeMesh) Close() {
-	v.buf.Delete()
+	v.backend.DeleteMesh(v.handle)
 }
 
 func (v *CubeMesh) Render() {
-	v.buf.Bind(gl.ARRAY_BUFFER)
-	gl.EnableClientState(gl.VERTEX_ARRAY)
-	defer gl.DisableClientState(gl.VERTEX_ARRAY)
-
-	gl.InterleavedArrays(gl.C4F_N3F_V3F, vertexF_Size, nil)
-	gl.DrawArrays(gl.QUADS, 0, v.length)
+	v.backend.DrawMesh(v.handle)
 }
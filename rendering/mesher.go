@@ -0,0 +1,187 @@
+package rendering
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// MeshRequest describes a chunk which should be meshed by a Mesher.
+type MeshRequest struct {
+	Chunk   Chunk
+	Options Options
+	// Priority defines the order of the requests. Requests with a lower
+	// priority are meshed first, e.g. the distance to the camera.
+	Priority float32
+	// Tag is passed to the result to identify the request.
+	Tag interface{}
+	// LOD meshes the downsampled levels of the chunk as well if it is set.
+	// The renderer of the result is a LODMesh then.
+	LOD *LODOptions
+}
+
+// MeshResult is the result of a MeshRequest. Err is set if the request was
+// cancelled.
+type MeshResult struct {
+	MeshRequest
+	Mesh  []VertexF
+	Stats MeshStats
+	Err   error
+
+	levels []lodLevel
+}
+
+// Renderer creates the renderer for the mesh. It has to be called on the
+// thread owning the GL context.
+func (r MeshResult) Renderer() Renderer {
+	if r.LOD != nil {
+		return newLODMeshFromLevels(r.Chunk, r.Mesh, r.levels, r.Options, *r.LOD)
+	}
+	return newRendererFromMesh(r.Chunk, r.Mesh, r.Options)
+}
+
+type meshJob struct {
+	ctx   context.Context
+	req   MeshRequest
+	index int
+}
+
+type meshQueue []*meshJob
+
+func (q meshQueue) Len() int {
+	return len(q)
+}
+
+func (q meshQueue) Less(i, j int) bool {
+	return q[i].req.Priority < q[j].req.Priority
+}
+
+func (q meshQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *meshQueue) Push(x interface{}) {
+	job := x.(*meshJob)
+	job.index = len(*q)
+	*q = append(*q, job)
+}
+
+func (q *meshQueue) Pop() interface{} {
+	old := *q
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return job
+}
+
+// Mesher meshes chunks in the background using a fixed number of workers.
+// The results are delivered through the Results channel which should be
+// drained by the thread owning the GL context.
+type Mesher struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   meshQueue
+	closed  bool
+	results chan MeshResult
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewMesher creates a Mesher with the given number of workers.
+func NewMesher(workers int) *Mesher {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Mesher{
+		results: make(chan MeshResult, workers*4),
+		done:    make(chan struct{}),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	m.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go m.work()
+	}
+	return m
+}
+
+// Submit queues the request. If the context is cancelled before the request
+// is completed, a result with the error of the context is delivered.
+func (m *Mesher) Submit(ctx context.Context, req MeshRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	heap.Push(&m.queue, &meshJob{ctx: ctx, req: req})
+	m.cond.Signal()
+}
+
+// Reprioritize updates the priority of all pending requests, e.g. after the
+// camera moved.
+func (m *Mesher) Reprioritize(priority func(req MeshRequest) float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.queue {
+		job.req.Priority = priority(job.req)
+	}
+	heap.Init(&m.queue)
+}
+
+// Pending returns the number of queued requests.
+func (m *Mesher) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// Results returns the channel receiving the meshed chunks.
+func (m *Mesher) Results() <-chan MeshResult {
+	return m.results
+}
+
+// Close stops all workers. Pending requests are dropped.
+func (m *Mesher) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	m.queue = nil
+	close(m.done)
+	m.cond.Broadcast()
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+func (m *Mesher) next() *meshJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.queue) == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if m.closed {
+		return nil
+	}
+	return heap.Pop(&m.queue).(*meshJob)
+}
+
+func (m *Mesher) work() {
+	defer m.wg.Done()
+	for job := m.next(); job != nil; job = m.next() {
+		res := MeshResult{MeshRequest: job.req}
+		res.Mesh, res.Stats, res.Err = CreateMeshFromChunkContext(job.ctx, job.req.Chunk, job.req.Options)
+		if res.Err == nil && job.req.LOD != nil {
+			if res.levels, res.Err = createLODLevels(job.ctx, job.req.Chunk, job.req.Options, *job.req.LOD); res.Err != nil {
+				res.Mesh = nil
+			}
+		}
+		select {
+		case m.results <- res:
+		case <-m.done:
+			return
+		}
+	}
+}
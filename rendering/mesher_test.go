@@ -0,0 +1,150 @@
+package rendering
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// blockingChunk blocks the first access to a voxel until release is closed.
+type blockingChunk struct {
+	*testChunk
+	started chan struct{}
+	release chan struct{}
+}
+
+func (bc *blockingChunk) At(pos mgl.Vec3I) Voxel {
+	select {
+	case <-bc.release:
+	default:
+		close(bc.started)
+		<-bc.release
+	}
+	return bc.testChunk.At(pos)
+}
+
+func receiveResult(t *testing.T, m *Mesher) MeshResult {
+	select {
+	case res := <-m.Results():
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for mesh result")
+	}
+	return MeshResult{}
+}
+
+func Test_MesherPriority(t *testing.T) {
+	m := NewMesher(1)
+	defer m.Close()
+
+	blocker := &blockingChunk{stairChunk(), make(chan struct{}), make(chan struct{})}
+	m.Submit(context.Background(), MeshRequest{Chunk: blocker, Tag: 0})
+	<-blocker.started
+
+	for _, prio := range []float32{3, 1, 2} {
+		m.Submit(context.Background(), MeshRequest{Chunk: stairChunk(), Priority: prio, Tag: int(prio)})
+	}
+	if p := m.Pending(); p != 3 {
+		t.Errorf("Expected 3 pending requests got %v", p)
+	}
+	m.Reprioritize(func(req MeshRequest) float32 {
+		if req.Tag == 3 {
+			return 0
+		}
+		return req.Priority
+	})
+	close(blocker.release)
+
+	expected, _ := CreateMeshFromChunk(stairChunk(), Options{})
+	for _, tag := range []int{0, 3, 1, 2} {
+		res := receiveResult(t, m)
+		if res.Tag != tag {
+			t.Errorf("Expected result %v got %v", tag, res.Tag)
+		}
+		if res.Err != nil || len(res.Mesh) != len(expected) {
+			t.Errorf("Invalid result %v: %v vertices, error %v", res.Tag, len(res.Mesh), res.Err)
+		}
+	}
+}
+
+func Test_MesherCancel(t *testing.T) {
+	m := NewMesher(2)
+	defer m.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.Submit(ctx, MeshRequest{Chunk: stairChunk()})
+	if res := receiveResult(t, m); res.Err != context.Canceled || res.Mesh != nil {
+		t.Errorf("Cancelled request should fail got %v", res.Err)
+	}
+}
+
+// cancellingChunk cancels the meshing after the first voxel was read.
+type cancellingChunk struct {
+	*testChunk
+	cancel context.CancelFunc
+}
+
+func (cc *cancellingChunk) At(pos mgl.Vec3I) Voxel {
+	cc.cancel()
+	return cc.testChunk.At(pos)
+}
+
+func Test_CreateMeshFromChunkContext(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{16, 16, 16}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	mesh, stats, err := CreateMeshFromChunkContext(ctx, &cancellingChunk{tc, cancel}, Options{})
+	if err != context.Canceled || mesh != nil {
+		t.Errorf("Meshing should be cancelled got %v", err)
+	}
+	if stats.VoxelsVisited >= 16*16*16 {
+		t.Errorf("Culling should stop after cancellation, visited %v voxels", stats.VoxelsVisited)
+	}
+
+	mesh, _, err = CreateMeshFromChunkContext(context.Background(), tc, Options{})
+	if err != nil || len(mesh) != 6*4 {
+		t.Errorf("Expected 6 quads got %v (%v)", len(mesh)/4, err)
+	}
+}
+
+func Test_AsyncMeshClosed(t *testing.T) {
+	m := NewMesher(1)
+	defer m.Close()
+
+	b := NewRecordingBackend()
+	am := NewAsyncMesh(m, MeshRequest{Chunk: stairChunk(), Options: Options{Backend: b}, Tag: "stairs"})
+	res := receiveResult(t, m)
+	if res.Tag != am || am.Tag() != "stairs" {
+		t.Errorf("Request should be tagged with the mesh got %v and %v", res.Tag, am.Tag())
+	}
+	am.Close()
+	am.SetResult(res)
+	if am.Ready() || b.LiveMeshes() != 0 {
+		t.Errorf("Result arriving after Close should be dropped, %v live meshes", b.LiveMeshes())
+	}
+}
+
+func Test_AsyncMeshLOD(t *testing.T) {
+	m := NewMesher(1)
+	defer m.Close()
+
+	b := NewRecordingBackend()
+	am := NewAsyncMesh(m, MeshRequest{Chunk: stairChunk(), Options: Options{Backend: b}, LOD: &LODOptions{Levels: 1}})
+	am.SetResult(receiveResult(t, m))
+	lm, ok := am.renderer.(*LODMesh)
+	if !ok || len(lm.levels) != 2 {
+		t.Fatalf("Expected a LOD mesh with 2 levels got %T", am.renderer)
+	}
+	am.RenderLOD(1)
+	if b.Count(OpDrawMesh) != 1 || b.Calls[len(b.Calls)-1].ModelView == mgl.Identity() {
+		t.Errorf("Coarse level should be drawn scaled: %v", b.Calls)
+	}
+	am.Close()
+	if b.LiveMeshes() != 0 {
+		t.Errorf("Levels were not deleted")
+	}
+}
@@ -1,7 +1,7 @@
 package rendering
 
 import (
-	"fmt"
+	"context"
 	"github.com/boombuler/voxel/mgl"
 	"math"
 	"sync"
@@ -21,6 +21,11 @@ const (
 	front faceDirection = 5
 )
 
+// axis returns the index of the axis the face direction is pointing to.
+func (f faceDirection) axis() int {
+	return int(f / 2)
+}
+
 func isVoxelSolid(v Voxel) bool {
 	if v == nil {
 		return false
@@ -33,7 +38,9 @@ func isVoxelSolid(v Voxel) bool {
 	return a >= uint32(math.MaxUint16)
 }
 
-func isVoxelInvisible(v Voxel) bool {
+// IsVoxelInvisible checks if the voxel is empty, has no color or is fully
+// transparent.
+func IsVoxelInvisible(v Voxel) bool {
 	if v == nil {
 		return true
 	}
@@ -69,42 +76,122 @@ func defaultVoxelIterator(c Chunk) func(fn func(p mgl.Vec3I, v Voxel)) {
 	}
 }
 
-func performCulling(c Chunk, noCulling bool) map[faceDirection]map[mgl.Vec3I]Voxel {
+// neighbourOffsets contains the offset to the neighbour voxel for each face direction
+var neighbourOffsets = [6]mgl.Vec3I{
+	left:   vLeftOf,
+	right:  vRightOf,
+	bottom: vBottomOf,
+	top:    vTopOf,
+	back:   vBackOf,
+	front:  vFrontOf,
+}
+
+// meshContext contains the state shared by the meshing steps of a chunk.
+type meshContext struct {
+	chunk     Chunk
+	bounds    mgl.Vec3I
+	noCulling bool
+	noMeshing bool
+	// noTJunctions splits the quads at the vertices of their neighbours
+	noTJunctions bool
+	mergeKey     MergeKeyFunc
+	// done is closed if the meshing should be aborted. nil for contexts
+	// which can't be cancelled.
+	done <-chan struct{}
+}
+
+// cancelCheckInterval is the number of voxels visited between two
+// cancellation checks while culling.
+const cancelCheckInterval = 1024
+
+// cancelled returns true if the meshing should be aborted.
+func (ctx *meshContext) cancelled() bool {
+	select {
+	case <-ctx.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func newMeshContext(c Chunk, o Options) *meshContext {
+	ctx := &meshContext{
+		chunk:        clipChunk(c, o.ClipPlanes),
+		bounds:       c.Size(),
+		noCulling:    o.NoCulling,
+		noMeshing:    o.NoMeshing,
+		noTJunctions: o.NoTJunctions,
+		mergeKey:     o.MergeKey,
+	}
+	if ctx.mergeKey == nil {
+		ctx.mergeKey = DefaultMergeKey
+	}
+	return ctx
+}
+
+// isFaceVisible checks if the face of the voxel vox at p in the given
+// direction is visible. Faces which are only partially occluded by their
+// neighbour are visible. Faces between translucent cubes with the same merge
+// key are hidden, faces of opaque cubes next to translucent ones are not.
+func (ctx *meshContext) isFaceVisible(p mgl.Vec3I, vox Voxel, dir faceDirection) bool {
+	if ctx.noCulling {
+		return true
+	}
+	n := p.Add(neighbourOffsets[dir])
+	axis := dir.axis()
+	if n[axis] < 0 || n[axis] >= ctx.bounds[axis] {
+		return true
+	}
+	nVox := ctx.chunk.At(n)
+	if faceArea(vox, dir)&^faceCover(nVox, dir.opposite()) == 0 {
+		return false
+	}
+	if IsVoxelInvisible(nVox) || shapeOf(vox) != nil || shapeOf(nVox) != nil {
+		return true
+	}
+	return isVoxelSolid(vox) || ctx.mergeKey(vox) != ctx.mergeKey(nVox)
+}
+
+// performCulling returns the visible faces of all cubes and all voxels with a
+// different shape. The number of visited voxels is stored in stats. Culling
+// stops visiting voxels if the context is cancelled.
+func (ctx *meshContext) performCulling(stats *MeshStats) (map[faceDirection]map[mgl.Vec3I]Voxel, map[mgl.Vec3I]Voxel) {
 	result := make(map[faceDirection]map[mgl.Vec3I]Voxel)
 	for f := faceDirection(0); f < faceDirection(6); f++ {
 		result[f] = make(map[mgl.Vec3I]Voxel)
 	}
-	bounds := c.Size()
+	shaped := make(map[mgl.Vec3I]Voxel)
 	var it func(fn func(p mgl.Vec3I, v Voxel))
-	if itChunk, ok := c.(IteratableChunk); ok {
+	if itChunk, ok := ctx.chunk.(IteratableChunk); ok {
 		it = itChunk.ForeachVoxel
 	} else {
-		it = defaultVoxelIterator(c)
+		it = defaultVoxelIterator(ctx.chunk)
 	}
 
+	aborted := false
 	it(func(p mgl.Vec3I, vox Voxel) {
-		if !isVoxelInvisible(vox) {
-			if noCulling || p.X() == 0 || !isVoxelSolid(c.At(p.Add(vLeftOf))) {
-				result[left][p] = vox
-			}
-			if noCulling || p.X() == bounds.X()-1 || !isVoxelSolid(c.At(p.Add(vRightOf))) {
-				result[right][p] = vox
-			}
-			if noCulling || p.Y() == 0 || !isVoxelSolid(c.At(p.Add(vBottomOf))) {
-				result[bottom][p] = vox
-			}
-			if noCulling || p.Y() == bounds.Y()-1 || !isVoxelSolid(c.At(p.Add(vTopOf))) {
-				result[top][p] = vox
-			}
-			if noCulling || p.Z() == 0 || !isVoxelSolid(c.At(p.Add(vBackOf))) {
-				result[back][p] = vox
-			}
-			if noCulling || p.Z() == bounds.Z()-1 || !isVoxelSolid(c.At(p.Add(vFrontOf))) {
-				result[front][p] = vox
+		if aborted {
+			return
+		}
+		stats.VoxelsVisited++
+		if stats.VoxelsVisited%cancelCheckInterval == 0 && ctx.cancelled() {
+			aborted = true
+			return
+		}
+		if IsVoxelInvisible(vox) {
+			return
+		}
+		if shapeOf(vox) != nil {
+			shaped[p] = vox
+			return
+		}
+		for f := faceDirection(0); f < faceDirection(6); f++ {
+			if ctx.isFaceVisible(p, vox, f) {
+				result[f][p] = vox
 			}
 		}
 	})
-	return result
+	return result, shaped
 }
 
 type meshingDirectionInfo struct {
@@ -153,7 +240,22 @@ var meshingDirections map[faceDirection]meshingDirectionInfo = map[faceDirection
 	},
 }
 
-func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []VertexF) {
+// appendQuad appends the quad spanned by e1 and e2 at start. The vertices are
+// ordered counter-clockwise when looking at the quad against its normal.
+func appendQuad(dst []VertexF, color Color, n, start, e1, e2 mgl.Vec3) []VertexF {
+	if e1.Cross(e2).Dot(n) < 0 {
+		e1, e2 = e2, e1
+	}
+	return append(dst,
+		VertexF{color, n, start},
+		VertexF{color, n, start.Add(e1)},
+		VertexF{color, n, start.Add(e1).Add(e2)},
+		VertexF{color, n, start.Add(e2)})
+}
+
+// perfomMeshing merges neighbouring faces of voxels with the same merge key
+// to larger quads.
+func (ctx *meshContext) perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []VertexF) {
 	result = make([]VertexF, 0, len(sides))
 	dinf := meshingDirections[dir]
 	d1, d2, n, offset := dinf.d1, dinf.d2, dinf.n, dinf.offset
@@ -161,18 +263,24 @@ func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []Verte
 	d1s := d1.Mul(-1)
 	d2s := d2.Mul(-1)
 
-	for len(sides) > 0 {
+	keys := make(map[mgl.Vec3I]interface{}, len(sides))
+	for k, v := range sides {
+		keys[k] = ctx.mergeKey(v)
+	}
+
+	for len(keys) > 0 && !ctx.cancelled() {
 		var startPos mgl.Vec3I
-		var checkVal Voxel
-		for k, v := range sides {
+		var checkVal interface{}
+		for k, v := range keys {
 			startPos = k
 			checkVal = v
 			break
 		}
+		startVox := sides[startPos]
 
 		for {
 			cpn := startPos.Add(d1s)
-			v, ok := sides[cpn]
+			v, ok := keys[cpn]
 			if ok && v == checkVal {
 				startPos = cpn
 			} else {
@@ -181,7 +289,7 @@ func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []Verte
 		}
 		for {
 			cpn := startPos.Add(d2s)
-			v, ok := sides[cpn]
+			v, ok := keys[cpn]
 			if ok && v == checkVal {
 				startPos = cpn
 			} else {
@@ -189,13 +297,13 @@ func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []Verte
 			}
 		}
 
-		delete(sides, startPos)
+		delete(keys, startPos)
 		width := 1
 		for {
 			t := startPos.Add(d1.Mul(width))
-			v, ok := sides[t]
+			v, ok := keys[t]
 			if ok && v == checkVal {
-				delete(sides, t)
+				delete(keys, t)
 				width++
 			} else {
 				break
@@ -208,7 +316,7 @@ func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []Verte
 			allOk := true
 			for i := 0; i < width; i++ {
 				t := startRow.Add(d1.Mul(i))
-				v, ok := sides[t]
+				v, ok := keys[t]
 				if !ok || v != checkVal {
 					allOk = false
 					break
@@ -218,29 +326,20 @@ func perfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []Verte
 				height++
 				for i := 0; i < width; i++ {
 					t := startRow.Add(d1.Mul(i))
-					delete(sides, t)
+					delete(keys, t)
 				}
 			} else {
 				break
 			}
 		}
 
-		pColor, ok := ColorModel.Convert(checkVal.Color()).(*Color)
+		pColor, ok := ColorModel.Convert(startVox.Color()).(*Color)
 		if !ok {
 			continue
 		}
 		color := *pColor
 
-		dm1 := mgl.Identity().TranslateVec3(d1.Mul(width).Vec3())
-		dm2 := mgl.Identity().TranslateVec3(d2.Mul(height).Vec3())
-		dm3 := dm1.MulMat4(dm2)
-		startPosV3 := startPos.Add(offset).Vec3()
-		startPosV4 := startPosV3.Vec4(1)
-		result = append(result,
-			VertexF{color, n, startPosV3},
-			VertexF{color, n, dm1.MulVec4(startPosV4).Vec3()},
-			VertexF{color, n, dm3.MulVec4(startPosV4).Vec3()},
-			VertexF{color, n, dm2.MulVec4(startPosV4).Vec3()})
+		result = appendQuad(result, color, n, startPos.Add(offset).Vec3(), d1.Mul(width).Vec3(), d2.Mul(height).Vec3())
 	}
 	return result
 }
@@ -256,50 +355,97 @@ func dontPerfomMeshing(sides map[mgl.Vec3I]Voxel, dir faceDirection) (result []V
 			continue
 		}
 		color := *pColor
-		startPos := pos.Add(offset)
 
-		result = append(result,
-			VertexF{color, n, startPos.Vec3()},
-			VertexF{color, n, startPos.Add(d1).Vec3()},
-			VertexF{color, n, startPos.Add(d1).Add(d2).Vec3()},
-			VertexF{color, n, startPos.Add(d2).Vec3()})
+		result = appendQuad(result, color, n, pos.Add(offset).Vec3(), d1.Vec3(), d2.Vec3())
 	}
 	return
 }
 
-func CreateMeshFromChunk(c Chunk, o Options) []VertexF {
+func (ctx *meshContext) meshSides(sides map[mgl.Vec3I]Voxel, dir faceDirection) []VertexF {
+	if ctx.noMeshing {
+		return dontPerfomMeshing(sides, dir)
+	}
+	return ctx.perfomMeshing(sides, dir)
+}
+
+// CreateMeshFromChunk creates the quads of all visible faces of the chunk.
+// The statistics of the meshing are returned and reported to the observer and
+// logger of the options.
+func CreateMeshFromChunk(c Chunk, o Options) ([]VertexF, MeshStats) {
+	mesh, stats, _ := newMeshContext(c, o).createMesh(true)
+	o.report(stats)
+	return mesh, stats
+}
+
+// CreateMeshFromChunkContext works like CreateMeshFromChunk but aborts if the
+// context is cancelled. The faces are meshed on the calling goroutine.
+func CreateMeshFromChunkContext(cc context.Context, c Chunk, o Options) ([]VertexF, MeshStats, error) {
+	if err := cc.Err(); err != nil {
+		return nil, MeshStats{}, err
+	}
+	ctx := newMeshContext(c, o)
+	ctx.done = cc.Done()
+	mesh, stats, aborted := ctx.createMesh(false)
+	if aborted {
+		return nil, stats, cc.Err()
+	}
+	o.report(stats)
+	return mesh, stats, nil
+}
+
+// createMesh culls and meshes the chunk. If parallel is set each direction is
+// meshed on its own goroutine. Returns true if the meshing was cancelled.
+func (ctx *meshContext) createMesh(parallel bool) ([]VertexF, MeshStats, bool) {
+	var stats MeshStats
 	t0 := time.Now()
-	culled := performCulling(c, o.HasFlag(NO_CULLING))
+	culled, shaped := ctx.performCulling(&stats)
 	t1 := time.Now()
+	if ctx.cancelled() {
+		return nil, stats, true
+	}
 
 	wg := new(sync.WaitGroup)
-	wg.Add(6)
 	results := make([][]VertexF, 6, 6)
 	for face, items := range culled {
 		f := face
 		i := items
+		stats.FacesPerDirection[f] = len(i)
+		if !parallel {
+			results[f] = ctx.meshSides(i, f)
+			continue
+		}
+		wg.Add(1)
 		go func() {
-			if o.HasFlag(NO_MESHING) {
-				results[f] = dontPerfomMeshing(i, f)
-			} else {
-				results[f] = perfomMeshing(i, f)
-			}
+			results[f] = ctx.meshSides(i, f)
 			wg.Done()
 		}()
 	}
 	wg.Wait()
+	if ctx.cancelled() {
+		return nil, stats, true
+	}
+	for f := range stats.QuadsPerDirection {
+		stats.Faces += stats.FacesPerDirection[f]
+		stats.QuadsPerDirection[f] = len(results[f]) / 4
+	}
+	stats.ShapedVoxels = len(shaped)
+	for p, vox := range shaped {
+		results = append(results, ctx.meshShapedVoxel(p, vox))
+	}
 	cnt := 0
-	for i := faceDirection(0); i < faceDirection(6); i++ {
-		cnt += len(results[i])
+	for _, r := range results {
+		cnt += len(r)
 	}
 	result := make([]VertexF, 0, cnt)
-	for i := faceDirection(0); i < faceDirection(6); i++ {
-		result = append(result, results[i]...)
+	for _, r := range results {
+		result = append(result, r...)
+	}
+	if ctx.noTJunctions {
+		result = removeTJunctions(result)
 	}
-	t2 := time.Now()
-	fmt.Println("Quad Count: ", cnt/4)
-	fmt.Println("Culling took:", t1.Sub(t0))
-	fmt.Println("Greedy took:", t2.Sub(t1))
+	stats.Quads = len(result) / 4
+	stats.Culling = t1.Sub(t0)
+	stats.Meshing = time.Since(t1)
 
-	return result
+	return result, stats, false
 }
@@ -0,0 +1,167 @@
+package rendering
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type testVoxel color.RGBA
+
+func (tv testVoxel) Color() color.Color {
+	return color.RGBA(tv)
+}
+
+var (
+	testRed  = testVoxel{255, 0, 0, 255}
+	testBlue = testVoxel{0, 0, 255, 255}
+)
+
+type testChunk struct {
+	size   mgl.Vec3I
+	voxels map[mgl.Vec3I]Voxel
+}
+
+func newTestChunk(size mgl.Vec3I) *testChunk {
+	return &testChunk{size, make(map[mgl.Vec3I]Voxel)}
+}
+
+func (tc *testChunk) Size() mgl.Vec3I {
+	return tc.size
+}
+
+func (tc *testChunk) At(pos mgl.Vec3I) Voxel {
+	return tc.voxels[pos]
+}
+
+// meshOf returns the quads of the chunk without statistics
+func meshOf(c Chunk, o Options) []VertexF {
+	mesh, _ := CreateMeshFromChunk(c, o)
+	return mesh
+}
+
+// stairChunk returns a small stair shaped model using two colors
+func stairChunk() *testChunk {
+	tc := newTestChunk(mgl.Vec3I{4, 4, 3})
+	for x := 0; x < 4; x++ {
+		for y := 0; y <= x; y++ {
+			for z := 0; z < 3; z++ {
+				if (x+z)%2 == 0 {
+					tc.voxels[mgl.Vec3I{x, y, z}] = testRed
+				} else {
+					tc.voxels[mgl.Vec3I{x, y, z}] = testBlue
+				}
+			}
+		}
+	}
+	return tc
+}
+
+func Test_IndexedMeshWinding(t *testing.T) {
+	tc := stairChunk()
+	for _, opt := range []Options{{}, {NoMeshing: true}, {NoCulling: true}, {NoCulling: true, NoMeshing: true}} {
+		verts, indices := CreateIndexedMeshFromChunk(tc, opt)
+		if len(indices) == 0 || len(indices)%3 != 0 {
+			t.Errorf("Invalid index count %v for options %v", len(indices), opt)
+			continue
+		}
+		for i := 0; i < len(indices); i += 3 {
+			a, b, c := verts[indices[i]], verts[indices[i+1]], verts[indices[i+2]]
+			if !a.Norm.Equals(b.Norm) || !a.Norm.Equals(c.Norm) {
+				t.Errorf("Triangle %v mixes normals: %v %v %v", i/3, a.Norm, b.Norm, c.Norm)
+				return
+			}
+			n := b.Pos.Sub(a.Pos).Cross(c.Pos.Sub(a.Pos))
+			if n.Dot(a.Norm) <= 0 {
+				t.Errorf("Triangle %v (%v, %v, %v) is not counter-clockwise to normal %v", i/3, a.Pos, b.Pos, c.Pos, a.Norm)
+				return
+			}
+		}
+	}
+}
+
+func Test_IndexedMeshWelding(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{1, 1, 1})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testRed
+
+	verts, indices := CreateIndexedMeshFromChunk(tc, Options{})
+	if len(verts) != 24 || len(indices) != 36 {
+		t.Errorf("Single cube got %v vertices and %v indices expected 24 and 36", len(verts), len(indices))
+	}
+
+	tc = newTestChunk(mgl.Vec3I{2, 1, 1})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = testRed
+
+	// without greedy meshing the long sides consist of two quads each sharing two vertices.
+	verts, indices = CreateIndexedMeshFromChunk(tc, Options{NoMeshing: true})
+	if len(verts) != 2*4+4*6 || len(indices) != 10*6 {
+		t.Errorf("Two cubes got %v vertices and %v indices expected %v and %v", len(verts), len(indices), 2*4+4*6, 10*6)
+	}
+}
+
+func Test_IndexQuadsDropsDegenerated(t *testing.T) {
+	n := mgl.Vec3{0, 0, 1}
+	quads := []VertexF{
+		{Color{1, 0, 0, 1}, n, mgl.Vec3{0, 0, 0}},
+		{Color{1, 0, 0, 1}, n, mgl.Vec3{1, 0, 0}},
+		{Color{1, 0, 0, 1}, n, mgl.Vec3{1, 1, 0}},
+		{Color{1, 0, 0, 1}, n, mgl.Vec3{1, 1, 0}},
+	}
+	verts, indices := IndexQuads(quads)
+	if len(verts) != 3 || len(indices) != 3 {
+		t.Errorf("Expected a single triangle got %v vertices and %v indices", len(verts), len(indices))
+	}
+}
+
+type unitFace struct {
+	pos mgl.Vec3I
+	n   mgl.Vec3I
+}
+
+// unitFaces splits the given quads into faces of a single voxel.
+func unitFaces(quads []VertexF) map[unitFace]Color {
+	result := make(map[unitFace]Color)
+	for i := 0; i+3 < len(quads); i += 4 {
+		lo, hi := quads[i].Pos.Vec3I(), quads[i].Pos.Vec3I()
+		for _, v := range quads[i+1 : i+4] {
+			p := v.Pos.Vec3I()
+			for j := 0; j < 3; j++ {
+				if p[j] < lo[j] {
+					lo[j] = p[j]
+				}
+				if p[j] > hi[j] {
+					hi[j] = p[j]
+				}
+			}
+		}
+		for j := 0; j < 3; j++ {
+			if hi[j] == lo[j] {
+				hi[j]++
+			}
+		}
+		for x := lo[0]; x < hi[0]; x++ {
+			for y := lo[1]; y < hi[1]; y++ {
+				for z := lo[2]; z < hi[2]; z++ {
+					result[unitFace{mgl.Vec3I{x, y, z}, quads[i].Norm.Vec3I()}] = quads[i].Color
+				}
+			}
+		}
+	}
+	return result
+}
+
+func compareUnitFaces(t *testing.T, got, expected []VertexF) {
+	gf, ef := unitFaces(got), unitFaces(expected)
+	if len(gf) != len(ef) {
+		t.Errorf("Got %v faces expected %v", len(gf), len(ef))
+		return
+	}
+	for f, c := range ef {
+		if gc, ok := gf[f]; !ok || gc != c {
+			t.Errorf("Face %v missing or wrong color", f)
+			return
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package rendering
+
+import (
+	"log/slog"
+	"time"
+)
+
+// MeshStats contains statistics about the creation of a mesh.
+type MeshStats struct {
+	// VoxelsVisited is the number of voxels checked while culling.
+	VoxelsVisited int
+	// Faces is the number of faces left after culling.
+	Faces int
+	// ShapedVoxels is the number of visible voxels which are not a cube.
+	ShapedVoxels int
+	// Quads is the number of quads of the mesh including shaped voxels and
+	// triangles stored as quads.
+	Quads int
+	// FacesPerDirection and QuadsPerDirection contain the cube faces and
+	// merged quads for the directions -X, +X, -Y, +Y, -Z and +Z.
+	FacesPerDirection [6]int
+	QuadsPerDirection [6]int
+
+	Culling time.Duration
+	Meshing time.Duration
+}
+
+// Total returns the time spent for culling and meshing.
+func (s MeshStats) Total() time.Duration {
+	return s.Culling + s.Meshing
+}
+
+// LogValue implements slog.LogValuer.
+func (s MeshStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("voxels", s.VoxelsVisited),
+		slog.Int("faces", s.Faces),
+		slog.Int("shaped", s.ShapedVoxels),
+		slog.Int("quads", s.Quads),
+		slog.Duration("culling", s.Culling),
+		slog.Duration("meshing", s.Meshing),
+	)
+}
+
+// report passes the statistics to the observer and logger of the options.
+func (o Options) report(stats MeshStats) {
+	if o.Observer != nil {
+		o.Observer(stats)
+	}
+	if o.Logger != nil {
+		o.Logger.Debug("mesh created", "stats", stats)
+	}
+}
+
+// warn logs that the options could not be applied as requested. It uses the
+// default logger if the options have none.
+func (o Options) warn(msg string, args ...interface{}) {
+	l := o.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	l.Warn(msg, args...)
+}
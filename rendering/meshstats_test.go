@@ -0,0 +1,51 @@
+package rendering
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_MeshStats(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{2, 2, 1})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{0, 1, 0}] = testShapedVoxel{testBlue, ShapeSlab, North}
+
+	var observed []MeshStats
+	buf := new(bytes.Buffer)
+	opt := Options{
+		Observer: func(s MeshStats) { observed = append(observed, s) },
+		Logger:   slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	mesh, stats := CreateMeshFromChunk(tc, opt)
+
+	if stats.VoxelsVisited != 4 {
+		t.Errorf("Expected 4 visited voxels got %v", stats.VoxelsVisited)
+	}
+	if stats.ShapedVoxels != 1 {
+		t.Errorf("Expected 1 shaped voxel got %v", stats.ShapedVoxels)
+	}
+	if stats.Quads != len(mesh)/4 {
+		t.Errorf("Quad count %v does not match mesh %v", stats.Quads, len(mesh)/4)
+	}
+	// The bottom of the slab hides the top of the cube below it.
+	expFaces := [6]int{1, 1, 2, 1, 2, 2}
+	expQuads := [6]int{1, 1, 1, 1, 1, 1}
+	if stats.FacesPerDirection != expFaces || stats.QuadsPerDirection != expQuads {
+		t.Errorf("Invalid per direction stats: faces %v quads %v", stats.FacesPerDirection, stats.QuadsPerDirection)
+	}
+	if stats.Faces != 9 {
+		t.Errorf("Expected 9 faces got %v", stats.Faces)
+	}
+
+	if len(observed) != 1 || observed[0].Quads != stats.Quads {
+		t.Errorf("Observer was not called with the stats: %v", observed)
+	}
+	if !strings.Contains(buf.String(), "stats.quads=") {
+		t.Errorf("Stats were not logged: %q", buf.String())
+	}
+}
@@ -0,0 +1,24 @@
+package rendering
+
+// PackedMesh renders packed vertices. The colors of the vertices are looked up
+// in the palette by the backend.
+type PackedMesh struct {
+	backend PackedBackend
+	handle  MeshHandle
+}
+
+func NewPackedMesh(b PackedBackend, verts []VertexP, palette []Color) (*PackedMesh, error) {
+	h, err := b.CreatePackedMesh(verts, palette)
+	if err != nil {
+		return nil, err
+	}
+	return &PackedMesh{b, h}, nil
+}
+
+func (v *PackedMesh) Close() {
+	v.backend.DeleteMesh(v.handle)
+}
+
+func (v *PackedMesh) Render() {
+	v.backend.DrawMesh(v.handle)
+}
@@ -0,0 +1,160 @@
+package rendering
+
+import (
+	"errors"
+	"math"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// VertexP is a compact representation of a VertexF. The position is stored as
+// integer voxel coordinates, the normal as index of the face direction, the
+// color as an index into a palette and the ambient occlusion level ranges from
+// 0 (fully occluded) to 3 (not occluded). The coordinates range from 0 to 255,
+// so chunks with more than 255 voxels along an axis can't be packed.
+type VertexP struct {
+	X, Y, Z uint8
+	Norm    uint8
+	Color   uint16
+	AO      uint8
+	_       uint8
+}
+
+const maxAOLevel = 3
+
+var (
+	errPackedPosition = errors.New("packed vertices require integer positions between 0 and 255")
+	errPackedNormal   = errors.New("packed vertices require axis aligned normals")
+	errPackedPalette  = errors.New("too many colors for a packed vertex palette")
+)
+
+// Pos returns the position of the vertex.
+func (v VertexP) Pos() mgl.Vec3 {
+	return mgl.Vec3{float32(v.X), float32(v.Y), float32(v.Z)}
+}
+
+// Normal returns the normal of the vertex.
+func (v VertexP) Normal() mgl.Vec3 {
+	return meshingDirections[faceDirection(v.Norm)].n
+}
+
+func packCoord(f float32) (uint8, bool) {
+	if f < 0 || f > math.MaxUint8 || f != float32(math.Trunc(float64(f))) {
+		return 0, false
+	}
+	return uint8(f), true
+}
+
+func packNormal(n mgl.Vec3) (uint8, bool) {
+	for dir, dinf := range meshingDirections {
+		if dinf.n.Equals(n) {
+			return uint8(dir), true
+		}
+	}
+	return 0, false
+}
+
+// PackVertices converts the given vertices to packed vertices and returns the
+// palette the color indices refer to. All vertices are marked as not occluded.
+func PackVertices(verts []VertexF) ([]VertexP, []Color, error) {
+	result := make([]VertexP, len(verts))
+	palette := make([]Color, 0)
+	palIdx := make(map[Color]uint16)
+
+	for i, v := range verts {
+		p := &result[i]
+		var okX, okY, okZ, okN bool
+		p.X, okX = packCoord(v.Pos.X())
+		p.Y, okY = packCoord(v.Pos.Y())
+		p.Z, okZ = packCoord(v.Pos.Z())
+		if !okX || !okY || !okZ {
+			return nil, nil, errPackedPosition
+		}
+		if p.Norm, okN = packNormal(v.Norm); !okN {
+			return nil, nil, errPackedNormal
+		}
+
+		idx, ok := palIdx[v.Color]
+		if !ok {
+			if len(palette) > math.MaxUint16 {
+				return nil, nil, errPackedPalette
+			}
+			idx = uint16(len(palette))
+			palIdx[v.Color] = idx
+			palette = append(palette, v.Color)
+		}
+		p.Color = idx
+		p.AO = maxAOLevel
+	}
+	return result, palette, nil
+}
+
+// UnpackVertices converts packed vertices back to vertices using the given palette.
+func UnpackVertices(verts []VertexP, palette []Color) []VertexF {
+	result := make([]VertexF, len(verts))
+	for i, v := range verts {
+		result[i] = VertexF{palette[v.Color], v.Normal(), v.Pos()}
+	}
+	return result
+}
+
+func isSolidWithin(c Chunk, bounds, p mgl.Vec3I) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < 0 || p[i] >= bounds[i] {
+			return false
+		}
+	}
+	return isVoxelSolid(c.At(p))
+}
+
+// cellOffset returns the offsets along an axis of the voxel cells touching a
+// corner, for the cell covered by a quad extending into direction toQuad and
+// for the cell beside the quad.
+func cellOffset(toQuad float32) (inside, outside int) {
+	if toQuad > 0 {
+		return 0, -1
+	}
+	return -1, 0
+}
+
+func aoLevel(side1, side2, corner bool) uint8 {
+	if side1 && side2 {
+		return 0
+	}
+	level := uint8(maxAOLevel)
+	for _, occ := range []bool{side1, side2, corner} {
+		if occ {
+			level--
+		}
+	}
+	return level
+}
+
+// ApplyAmbientOcclusion calculates the ambient occlusion level of the given
+// quads (as returned by PackVertices) by inspecting the voxels in front of the
+// face around each corner.
+func ApplyAmbientOcclusion(c Chunk, quads []VertexP) {
+	bounds := c.Size()
+	for i := 0; i+3 < len(quads); i += 4 {
+		q := quads[i : i+4]
+		dinf := meshingDirections[faceDirection(q[0].Norm)]
+		center := q[0].Pos().Add(q[1].Pos()).Add(q[2].Pos()).Add(q[3].Pos()).Mul(0.25)
+		layer := dinf.n.Vec3I().Sub(dinf.offset)
+
+		for j := range q {
+			pos := q[j].Pos()
+			toCenter := center.Sub(pos)
+			in1, out1 := cellOffset(toCenter.Dot(dinf.d1.Vec3()))
+			in2, out2 := cellOffset(toCenter.Dot(dinf.d2.Vec3()))
+
+			base := pos.Vec3I().Add(layer)
+			cell := func(o1, o2 int) mgl.Vec3I {
+				return base.Add(dinf.d1.Mul(o1)).Add(dinf.d2.Mul(o2))
+			}
+			q[j].AO = aoLevel(
+				isSolidWithin(c, bounds, cell(out1, in2)),
+				isSolidWithin(c, bounds, cell(in1, out2)),
+				isSolidWithin(c, bounds, cell(out1, out2)))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_PackVertices(t *testing.T) {
+	tc := stairChunk()
+	mesh := meshOf(tc, Options{})
+	packed, palette, err := PackVertices(mesh)
+	if err != nil {
+		t.Fatalf("Failed to pack vertices: %v", err)
+	}
+	if len(palette) != 2 {
+		t.Errorf("Expected a palette with 2 colors got %v", len(palette))
+	}
+	unpacked := UnpackVertices(packed, palette)
+	for i, v := range mesh {
+		if unpacked[i] != v {
+			t.Errorf("Packing changed vertex %v: got %v expected %v", i, unpacked[i], v)
+			return
+		}
+	}
+}
+
+func Test_PackVerticesErrors(t *testing.T) {
+	tests := []VertexF{
+		{Color{1, 0, 0, 1}, mgl.Vec3{0, 1, 0}, mgl.Vec3{0.5, 0, 0}},
+		{Color{1, 0, 0, 1}, mgl.Vec3{0, 1, 0}, mgl.Vec3{256, 0, 0}},
+		{Color{1, 0, 0, 1}, mgl.Vec3{0, 1, 0}, mgl.Vec3{-1, 0, 0}},
+		{Color{1, 0, 0, 1}, mgl.Vec3{0, 1, 1}, mgl.Vec3{0, 0, 0}},
+	}
+	for _, v := range tests {
+		if _, _, err := PackVertices([]VertexF{v}); err == nil {
+			t.Errorf("Expected an error when packing %v", v)
+		}
+	}
+}
+
+func Test_ApplyAmbientOcclusion(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{3, 3, 3})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = testRed
+	tc.voxels[mgl.Vec3I{1, 1, 0}] = testRed
+
+	packed, _, err := PackVertices(meshOf(tc, Options{NoMeshing: true}))
+	if err != nil {
+		t.Fatalf("Failed to pack vertices: %v", err)
+	}
+	ApplyAmbientOcclusion(tc, packed)
+
+	expected := map[mgl.Vec3I]uint8{
+		{0, 1, 0}: 3,
+		{0, 1, 1}: 3,
+		{1, 1, 0}: 2,
+		{1, 1, 1}: 2,
+	}
+	found := 0
+	for i := 0; i < len(packed); i += 4 {
+		q := packed[i : i+4]
+		if faceDirection(q[0].Norm) != top || q[0].Y != 1 {
+			continue
+		}
+		for _, v := range q {
+			found++
+			pos := v.Pos().Vec3I()
+			if v.AO != expected[pos] {
+				t.Errorf("Invalid AO level at %v: got %v expected %v", pos, v.AO, expected[pos])
+			}
+		}
+	}
+	if found != 4 {
+		t.Errorf("Expected one top face at y=1 got %v vertices", found)
+	}
+}
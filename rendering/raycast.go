@@ -0,0 +1,208 @@
+package rendering
+
+import (
+	"math"
+	"sort"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// RaycastHit describes a voxel hit by a ray.
+type RaycastHit struct {
+	// Pos is the position of the voxel within its chunk.
+	Pos   mgl.Vec3I
+	Voxel Voxel
+	// Normal is the normal of the face the ray enters the voxel through.
+	Normal mgl.Vec3I
+	// Distance is the distance along the ray where it enters the voxel.
+	Distance float32
+	// Object is the object containing the voxel. It is only set by
+	// RaycastObjects.
+	Object Object
+}
+
+// ChunkObject is implemented by objects which can be hit by RaycastObjects.
+type ChunkObject interface {
+	Object
+	Chunk() Chunk
+}
+
+// Raycast returns the first visible voxel of the chunk hit by the ray within
+// maxDist. The ray is given in voxel coordinates of the chunk. A ray with a
+// zero direction hits nothing.
+func Raycast(c Chunk, origin, dir mgl.Vec3, maxDist float32) (RaycastHit, bool) {
+	var res RaycastHit
+	found := false
+	if !isValidRay(origin, dir) {
+		return res, false
+	}
+	WalkVoxels(c, origin, dir.Normalize(), maxDist, func(hit RaycastHit) bool {
+		if IsVoxelInvisible(hit.Voxel) {
+			return true
+		}
+		res, found = hit, true
+		return false
+	})
+	return res, found
+}
+
+// RaycastObjects returns the first voxel hit by the ray within maxDist. The
+// ray is given in world coordinates, where the voxels of an object are scaled
+// by scale and start at the position of the object. Objects which do not
+// implement ChunkObject are ignored.
+func RaycastObjects(objects []Object, origin, dir mgl.Vec3, maxDist, scale float32) (RaycastHit, bool) {
+	if !isValidRay(origin, dir) {
+		return RaycastHit{}, false
+	}
+	dir = dir.Normalize()
+	ray := mgl.Ray{Origin: origin, Dir: dir}
+
+	type candidate struct {
+		obj   ChunkObject
+		enter float32
+	}
+	var candidates []candidate
+	for _, obj := range objects {
+		co, ok := obj.(ChunkObject)
+		if !ok {
+			continue
+		}
+		bounds := mgl.AABBFromSize(obj.Position(), obj.Size().Mul(scale))
+		if t, _, ok := ray.IntersectAABB(bounds); ok && t <= maxDist {
+			candidates = append(candidates, candidate{co, t})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].enter < candidates[j].enter
+	})
+
+	var res RaycastHit
+	found := false
+	for _, cand := range candidates {
+		if found && cand.enter > res.Distance {
+			// the remaining objects start behind the hit.
+			break
+		}
+		local := origin.Sub(cand.obj.Position()).Mul(1 / scale)
+		hit, ok := Raycast(cand.obj.Chunk(), local, dir, maxDist/scale)
+		if ok && (!found || hit.Distance*scale < res.Distance) {
+			hit.Distance *= scale
+			hit.Object = cand.obj
+			res, found = hit, true
+		}
+	}
+	return res, found
+}
+
+// isValidRay checks if the ray has a finite origin and a finite direction
+// other than zero.
+func isValidRay(origin, dir mgl.Vec3) bool {
+	zero := true
+	for i := 0; i < 3; i++ {
+		o, d := float64(origin[i]), float64(dir[i])
+		if math.IsNaN(o) || math.IsInf(o, 0) || math.IsNaN(d) || math.IsInf(d, 0) {
+			return false
+		}
+		zero = zero && d == 0
+	}
+	return !zero
+}
+
+// WalkVoxels visits the voxels of the chunk along the ray using the algorithm
+// of Amanatides and Woo. fn is called for every voxel within maxDist in the
+// order they are hit until it returns false. Distances are measured in
+// multiples of dir. Nothing is visited for a zero or non-finite ray.
+func WalkVoxels(c Chunk, origin, dir mgl.Vec3, maxDist float32, fn func(hit RaycastHit) bool) {
+	if !isValidRay(origin, dir) {
+		return
+	}
+	size := c.Size()
+	// clip the ray at the bounds of the chunk.
+	tEnter, tExit := float32(0), maxDist
+	enterAxis := -1
+	for i := 0; i < 3; i++ {
+		if dir[i] == 0 {
+			if origin[i] < 0 || origin[i] >= float32(size[i]) {
+				return
+			}
+			continue
+		}
+		t1 := -origin[i] / dir[i]
+		t2 := (float32(size[i]) - origin[i]) / dir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tEnter {
+			tEnter, enterAxis = t1, i
+		}
+		if t2 < tExit {
+			tExit = t2
+		}
+	}
+	if tEnter > tExit {
+		return
+	}
+
+	p := origin.Add(dir.Mul(tEnter))
+	var pos, step, normal mgl.Vec3I
+	var tMax, tDelta mgl.Vec3
+	for i := 0; i < 3; i++ {
+		pos[i] = int(math.Floor(float64(p[i])))
+		if pos[i] < 0 {
+			pos[i] = 0
+		} else if pos[i] >= size[i] {
+			pos[i] = size[i] - 1
+		}
+		switch {
+		case dir[i] > 0:
+			step[i] = 1
+			tMax[i] = (float32(pos[i]+1) - origin[i]) / dir[i]
+			tDelta[i] = 1 / dir[i]
+		case dir[i] < 0:
+			step[i] = -1
+			tMax[i] = (float32(pos[i]) - origin[i]) / dir[i]
+			tDelta[i] = -1 / dir[i]
+		default:
+			tMax[i] = float32(math.Inf(1))
+		}
+	}
+	if enterAxis >= 0 {
+		normal[enterAxis] = -step[enterAxis]
+	} else {
+		// The ray starts inside a voxel, use the axis it is mostly facing.
+		axis := 0
+		for i := 1; i < 3; i++ {
+			if math.Abs(float64(dir[i])) > math.Abs(float64(dir[axis])) {
+				axis = i
+			}
+		}
+		normal[axis] = -step[axis]
+	}
+
+	t := tEnter
+	for {
+		if vox := c.At(pos); vox != nil {
+			if !fn(RaycastHit{Pos: pos, Voxel: vox, Normal: normal, Distance: t}) {
+				return
+			}
+		}
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t = tMax[axis]
+		if t > tExit {
+			return
+		}
+		pos[axis] += step[axis]
+		if pos[axis] < 0 || pos[axis] >= size[axis] {
+			return
+		}
+		tMax[axis] += tDelta[axis]
+		normal = mgl.Vec3I{}
+		normal[axis] = -step[axis]
+	}
+}
@@ -0,0 +1,174 @@
+package rendering
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+func Test_WalkVoxelsOrder(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{8, 4, 4})
+	for x := 0; x < 8; x++ {
+		tc.voxels[mgl.Vec3I{x, 1, 2}] = testRed
+	}
+	var visited []int
+	WalkVoxels(tc, mgl.Vec3{-3, 1.5, 2.5}, mgl.Vec3{1, 0, 0}, 100, func(h RaycastHit) bool {
+		if h.Normal != (mgl.Vec3I{-1, 0, 0}) {
+			t.Errorf("Expected normal -X but got %v", h.Normal)
+		}
+		if exp := float32(3 + h.Pos[0]); h.Distance != exp {
+			t.Errorf("Expected voxel %v at distance %v but got %v", h.Pos, exp, h.Distance)
+		}
+		visited = append(visited, h.Pos[0])
+		return len(visited) < 5
+	})
+	if len(visited) != 5 {
+		t.Fatalf("Expected the walk to stop after 5 voxels but got %v", visited)
+	}
+	for i, x := range visited {
+		if x != i {
+			t.Errorf("Expected voxel %d at step %d but got %d", i, i, x)
+		}
+	}
+}
+
+func Test_WalkVoxelsDiagonal(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{4, 4, 4})
+	tc.voxels[mgl.Vec3I{3, 3, 0}] = testRed
+	tc.voxels[mgl.Vec3I{0, 3, 0}] = testRed
+
+	var hits []RaycastHit
+	WalkVoxels(tc, mgl.Vec3{-1, -0.5, 0.5}, mgl.Vec3{1, 1, 0}.Normalize(), 100, func(h RaycastHit) bool {
+		hits = append(hits, h)
+		return true
+	})
+	if len(hits) != 1 || hits[0].Pos != (mgl.Vec3I{3, 3, 0}) {
+		t.Fatalf("Expected to hit only voxel {3 3 0} but got %v", hits)
+	}
+
+	hits = nil
+	WalkVoxels(tc, mgl.Vec3{-1, -0.5, 0.5}, mgl.Vec3{-1, 1, 0}.Normalize(), 100, func(h RaycastHit) bool {
+		hits = append(hits, h)
+		return true
+	})
+	if len(hits) != 0 {
+		t.Errorf("Expected a ray pointing away from the chunk to miss but got %v", hits)
+	}
+}
+
+func Test_Raycast(t *testing.T) {
+	tc := stairChunk()
+	tests := []struct {
+		origin, dir mgl.Vec3
+		maxDist     float32
+		ok          bool
+		pos, normal mgl.Vec3I
+		dist        float32
+	}{
+		// looking down on the steps
+		{mgl.Vec3{0.5, 10, 1.5}, mgl.Vec3{0, -1, 0}, 100, true, mgl.Vec3I{0, 0, 1}, mgl.Vec3I{0, 1, 0}, 9},
+		{mgl.Vec3{3.5, 10, 1.5}, mgl.Vec3{0, -2, 0}, 100, true, mgl.Vec3I{3, 3, 1}, mgl.Vec3I{0, 1, 0}, 6},
+		// looking at the front of the stairs along -X
+		{mgl.Vec3{10, 1.5, 0.5}, mgl.Vec3{-1, 0, 0}, 100, true, mgl.Vec3I{3, 1, 0}, mgl.Vec3I{1, 0, 0}, 6},
+		// the voxel is behind maxDist
+		{mgl.Vec3{10, 1.5, 0.5}, mgl.Vec3{-1, 0, 0}, 5, false, mgl.Vec3I{}, mgl.Vec3I{}, 0},
+		// passing above the stairs
+		{mgl.Vec3{-1, 4.5, 1.5}, mgl.Vec3{1, 0, 0}, 100, false, mgl.Vec3I{}, mgl.Vec3I{}, 0},
+	}
+	for _, test := range tests {
+		hit, ok := Raycast(tc, test.origin, test.dir, test.maxDist)
+		if ok != test.ok {
+			t.Errorf("Ray from %v along %v: expected hit %v but got %v", test.origin, test.dir, test.ok, hit)
+			continue
+		}
+		if ok && (hit.Pos != test.pos || hit.Normal != test.normal || !mgl.FloatEqual(hit.Distance, test.dist) || hit.Voxel == nil) {
+			t.Errorf("Ray from %v along %v: expected %v %v at %v but got %v %v at %v",
+				test.origin, test.dir, test.pos, test.normal, test.dist, hit.Pos, hit.Normal, hit.Distance)
+		}
+	}
+}
+
+func Test_RaycastSkipsInvisible(t *testing.T) {
+	tc := newTestChunk(mgl.Vec3I{4, 1, 1})
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = testVoxel{0, 0, 0, 0}
+	tc.voxels[mgl.Vec3I{2, 0, 0}] = testRed
+	hit, ok := Raycast(tc, mgl.Vec3{-1, 0.5, 0.5}, mgl.Vec3{1, 0, 0}, 100)
+	if !ok || hit.Pos != (mgl.Vec3I{2, 0, 0}) {
+		t.Errorf("Expected to hit the solid voxel behind the invisible one but got %v", hit)
+	}
+}
+
+func Test_RaycastInvalidDirection(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{2, 2, 2}, func(p mgl.Vec3I) Voxel { return testRed })
+	inf := float32(math.Inf(1))
+	nan := float32(math.NaN())
+	for _, dir := range []mgl.Vec3{{}, {nan, 0, 0}, {inf, 1, 0}} {
+		if hit, ok := Raycast(tc, mgl.Vec3{0.5, 0.5, 0.5}, dir, inf); ok {
+			t.Errorf("Expected no hit along %v but got %v", dir, hit)
+		}
+		obj := &testChunkObject{mgl.Vec3{}, tc}
+		if hit, ok := RaycastObjects([]Object{obj}, mgl.Vec3{0.5, 0.5, 0.5}, dir, inf, 1); ok {
+			t.Errorf("Expected no object hit along %v but got %v", dir, hit)
+		}
+	}
+}
+
+type testChunkObject struct {
+	pos   mgl.Vec3
+	chunk Chunk
+}
+
+func (o *testChunkObject) Position() mgl.Vec3 {
+	return o.pos
+}
+func (o *testChunkObject) Size() mgl.Vec3 {
+	return o.chunk.Size().Vec3()
+}
+func (o *testChunkObject) Renderer() Renderer {
+	return nil
+}
+func (o *testChunkObject) Chunk() Chunk {
+	return o.chunk
+}
+
+type plainObject struct{}
+
+func (plainObject) Position() mgl.Vec3 {
+	return mgl.Vec3{}
+}
+func (plainObject) Size() mgl.Vec3 {
+	return mgl.Vec3{100, 100, 100}
+}
+func (plainObject) Renderer() Renderer {
+	return nil
+}
+
+func Test_RaycastObjects(t *testing.T) {
+	cube := filledChunk(mgl.Vec3I{2, 2, 2}, func(p mgl.Vec3I) Voxel { return testRed })
+	near := &testChunkObject{mgl.Vec3{0, 0, -2}, cube}
+	far := &testChunkObject{mgl.Vec3{0, 0, -6}, cube}
+	objects := []Object{plainObject{}, far, near}
+
+	// objects are scaled to half the size of a voxel
+	hit, ok := RaycastObjects(objects, mgl.Vec3{0.25, 0.75, 5}, mgl.Vec3{0, 0, -1}, 100, 0.5)
+	if !ok || hit.Object != near {
+		t.Fatalf("Expected to hit the near object but got %v", hit)
+	}
+	if hit.Pos != (mgl.Vec3I{0, 1, 1}) || hit.Normal != (mgl.Vec3I{0, 0, 1}) || !mgl.FloatEqual(hit.Distance, 6) {
+		t.Errorf("Wrong hit %v %v at %v", hit.Pos, hit.Normal, hit.Distance)
+	}
+
+	// the ray passes the near object
+	hit, ok = RaycastObjects(objects, mgl.Vec3{0.25, 0.75, -3}, mgl.Vec3{0, 0, -1}, 100, 0.5)
+	if !ok || hit.Object != far || !mgl.FloatEqual(hit.Distance, 2) {
+		t.Errorf("Expected to hit the far object at 2 but got %v", hit)
+	}
+
+	if _, ok := RaycastObjects(objects, mgl.Vec3{0.25, 0.75, 5}, mgl.Vec3{0, 0, -1}, 5.5, 0.5); ok {
+		t.Errorf("Expected no hit within maxDist")
+	}
+	if _, ok := RaycastObjects(objects, mgl.Vec3{5, 0.75, 5}, mgl.Vec3{0, 0, -1}, 100, 0.5); ok {
+		t.Errorf("Expected the ray to miss all objects")
+	}
+}
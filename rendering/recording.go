@@ -0,0 +1,171 @@
+package rendering
+
+import (
+	"fmt"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// BackendOp identifies the operation of a recorded backend call.
+type BackendOp int
+
+const (
+	OpCreateMesh BackendOp = iota
+	OpUploadMesh
+	OpDeleteMesh
+	OpDrawMesh
+	OpDrawQuads
+	OpClear
+)
+
+func (op BackendOp) String() string {
+	switch op {
+	case OpCreateMesh:
+		return "CreateMesh"
+	case OpUploadMesh:
+		return "UploadMesh"
+	case OpDeleteMesh:
+		return "DeleteMesh"
+	case OpDrawMesh:
+		return "DrawMesh"
+	case OpDrawQuads:
+		return "DrawQuads"
+	case OpClear:
+		return "Clear"
+	}
+	return fmt.Sprintf("BackendOp(%d)", int(op))
+}
+
+// RecordedCall is a call to a RecordingBackend.
+type RecordedCall struct {
+	Op     BackendOp
+	Handle MeshHandle
+	// Quads is the number of quads of the mesh.
+	Quads int
+	// ModelView is the model view matrix at the time of the call.
+	ModelView mgl.Mat4
+}
+
+// RecordingBackend is a headless Backend which records all mesh operations.
+// It is meant to test the rendering logic without a graphics context and
+// panics if an unknown mesh is used.
+type RecordingBackend struct {
+	Calls []RecordedCall
+
+	meshes     map[MeshHandle][]VertexF
+	next       MeshHandle
+	projection mgl.Mat4
+	modelView  mgl.Mat4
+	stack      []mgl.Mat4
+}
+
+func NewRecordingBackend() *RecordingBackend {
+	return &RecordingBackend{
+		meshes:     make(map[MeshHandle][]VertexF),
+		projection: mgl.Identity(),
+		modelView:  mgl.Identity(),
+	}
+}
+
+func (b *RecordingBackend) record(op BackendOp, h MeshHandle, quads int) {
+	b.Calls = append(b.Calls, RecordedCall{op, h, quads, b.modelView})
+}
+
+func (b *RecordingBackend) mesh(h MeshHandle) []VertexF {
+	m, ok := b.meshes[h]
+	if !ok {
+		panic(fmt.Sprintf("unknown mesh %v", h))
+	}
+	return m
+}
+
+// Mesh returns the quads of a mesh.
+func (b *RecordingBackend) Mesh(h MeshHandle) ([]VertexF, bool) {
+	m, ok := b.meshes[h]
+	return m, ok
+}
+
+// LiveMeshes returns the number of meshes which are not deleted.
+func (b *RecordingBackend) LiveMeshes() int {
+	return len(b.meshes)
+}
+
+// Count returns how often the operation was called.
+func (b *RecordingBackend) Count(op BackendOp) int {
+	cnt := 0
+	for _, c := range b.Calls {
+		if c.Op == op {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// Reset removes all recorded calls.
+func (b *RecordingBackend) Reset() {
+	b.Calls = b.Calls[:0]
+}
+
+func (b *RecordingBackend) CreateMesh(quads []VertexF) MeshHandle {
+	b.next++
+	b.meshes[b.next] = append([]VertexF(nil), quads...)
+	b.record(OpCreateMesh, b.next, len(quads)/4)
+	return b.next
+}
+
+// CreatePackedMesh stores the unpacked vertices.
+func (b *RecordingBackend) CreatePackedMesh(verts []VertexP, palette []Color) (MeshHandle, error) {
+	return b.CreateMesh(UnpackVertices(verts, palette)), nil
+}
+
+func (b *RecordingBackend) UploadMesh(h MeshHandle, quads []VertexF) {
+	b.mesh(h)
+	b.meshes[h] = append([]VertexF(nil), quads...)
+	b.record(OpUploadMesh, h, len(quads)/4)
+}
+
+func (b *RecordingBackend) DeleteMesh(h MeshHandle) {
+	m := b.mesh(h)
+	delete(b.meshes, h)
+	b.record(OpDeleteMesh, h, len(m)/4)
+}
+
+func (b *RecordingBackend) DrawMesh(h MeshHandle) {
+	b.record(OpDrawMesh, h, len(b.mesh(h))/4)
+}
+
+func (b *RecordingBackend) DrawQuads(quads []VertexF) {
+	b.record(OpDrawQuads, 0, len(quads)/4)
+}
+
+func (b *RecordingBackend) Clear() {
+	b.record(OpClear, 0, 0)
+}
+
+func (b *RecordingBackend) SetProjection(m mgl.Mat4) {
+	b.projection = m
+}
+
+func (b *RecordingBackend) Projection() mgl.Mat4 {
+	return b.projection
+}
+
+func (b *RecordingBackend) SetModelView(m mgl.Mat4) {
+	b.modelView = m
+}
+
+func (b *RecordingBackend) ModelView() mgl.Mat4 {
+	return b.modelView
+}
+
+func (b *RecordingBackend) PushTransform(m mgl.Mat4) {
+	b.stack = append(b.stack, b.modelView)
+	b.modelView = b.modelView.MulMat4(m)
+}
+
+func (b *RecordingBackend) PopTransform() {
+	b.modelView = b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+}
+
+var _ PackedBackend = (*RecordingBackend)(nil)
@@ -1,5 +1,9 @@
 package rendering
 
+import (
+	"log/slog"
+)
+
 type Renderer interface {
 	Render()
 }
@@ -15,15 +19,33 @@ func (rf RenderFunc) Render() {
 	rf()
 }
 
-type Options int
-
-const (
-	NONE       Options = 0
-	NO_CULLING Options = 1 << iota
-	NO_MESHING
-	NO_VBO
-)
-
-func (o Options) HasFlag(opt Options) bool {
-	return o&opt == opt
+// Options controls how chunks are meshed and rendered.
+type Options struct {
+	// Backend draws the meshes of the renderers. It is only needed to
+	// render, creating a renderer without it panics.
+	Backend Backend
+	// NoCulling disables the removal of hidden faces.
+	NoCulling bool
+	// NoMeshing disables the merging of neighbouring faces.
+	NoMeshing bool
+	// NoTJunctions splits the edges of merged quads at the vertices of their
+	// neighbours, so all edges are shared by exactly two faces of a closed
+	// model. Split quads are emitted as triangles with a duplicated last vertex.
+	NoTJunctions bool
+	// NoVBO renders the mesh in immediate mode.
+	NoVBO bool
+	// Packed uploads the mesh using the packed vertex format if possible. It
+	// is limited to chunks of at most 255 voxels along each axis. A warning is
+	// logged if the mesh is uploaded unpacked instead.
+	Packed bool
+	// MergeKey decides which faces can be merged. Defaults to DefaultMergeKey.
+	MergeKey MergeKeyFunc
+	// ClipPlanes hide all voxels in front of any of the planes.
+	ClipPlanes []ClipPlane
+	// Observer is called with the statistics of every created mesh.
+	Observer func(stats MeshStats)
+	// Logger receives the statistics of every created mesh at debug level and
+	// warnings about options which could not be applied. The default logger
+	// is used for warnings if it is nil.
+	Logger *slog.Logger
 }
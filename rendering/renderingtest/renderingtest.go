@@ -0,0 +1,74 @@
+// Package renderingtest provides voxels and chunks for the tests of packages
+// using the rendering package.
+package renderingtest
+
+import (
+	"image/color"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+)
+
+// Voxel is a voxel with a fixed color.
+type Voxel color.RGBA
+
+var (
+	Red   = Voxel{255, 0, 0, 255}
+	Blue  = Voxel{0, 0, 255, 255}
+	White = Voxel{255, 255, 255, 255}
+)
+
+func (v Voxel) Color() color.Color {
+	return color.RGBA(v)
+}
+
+// Chunk stores its voxels in a map. Fill is returned for all positions which
+// are not in the map.
+type Chunk struct {
+	Voxels map[mgl.Vec3I]rendering.Voxel
+	Fill   rendering.Voxel
+	size   mgl.Vec3I
+}
+
+// NewChunk creates an empty chunk of the given size.
+func NewChunk(size mgl.Vec3I) *Chunk {
+	return &Chunk{
+		Voxels: make(map[mgl.Vec3I]rendering.Voxel),
+		size:   size,
+	}
+}
+
+// FilledChunk creates a chunk of the given size filled with the voxel.
+func FilledChunk(size mgl.Vec3I, vox rendering.Voxel) *Chunk {
+	c := NewChunk(size)
+	c.Fill = vox
+	return c
+}
+
+// StairChunk returns a small stair shaped model using two colors.
+func StairChunk() *Chunk {
+	c := NewChunk(mgl.Vec3I{4, 4, 3})
+	for x := 0; x < 4; x++ {
+		for y := 0; y <= x; y++ {
+			for z := 0; z < 3; z++ {
+				if (x+z)%2 == 0 {
+					c.Voxels[mgl.Vec3I{x, y, z}] = Red
+				} else {
+					c.Voxels[mgl.Vec3I{x, y, z}] = Blue
+				}
+			}
+		}
+	}
+	return c
+}
+
+func (c *Chunk) Size() mgl.Vec3I {
+	return c.size
+}
+
+func (c *Chunk) At(pos mgl.Vec3I) rendering.Voxel {
+	if vox, ok := c.Voxels[pos]; ok {
+		return vox
+	}
+	return c.Fill
+}
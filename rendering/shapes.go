@@ -0,0 +1,310 @@
+package rendering
+
+import (
+	"math"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// ShapeID identifies the geometry of a ShapedVoxel.
+type ShapeID byte
+
+const (
+	ShapeCube ShapeID = iota
+	// ShapeSlab is the lower half of a cube.
+	ShapeSlab
+	// ShapeStairs is a slab with a step on its front (+Z) half.
+	ShapeStairs
+	// ShapeSlope is a 45° ramp rising towards +Z.
+	ShapeSlope
+	// ShapeCross consists of two diagonal double sided quads as used for foliage.
+	ShapeCross
+
+	shapeCount = iota
+)
+
+// Orientation rotates a shape around the Y axis in steps of 90 degrees.
+// It might be combined with UpsideDown to flip the shape vertically.
+type Orientation byte
+
+const (
+	North Orientation = iota
+	East
+	South
+	West
+	UpsideDown Orientation = 4
+
+	orientationCount = 8
+)
+
+// ShapedVoxel is implemented by voxels which are not a full cube.
+type ShapedVoxel interface {
+	Voxel
+	Shape() ShapeID
+	Orientation() Orientation
+}
+
+// faceMask is a 4x4 raster of a face of the unit cube.
+type faceMask uint16
+
+const (
+	maskResolution          = 4
+	fullMask       faceMask = math.MaxUint16
+)
+
+type shapePolygon struct {
+	verts    []mgl.Vec3
+	n        mgl.Vec3
+	face     faceDirection
+	boundary bool
+}
+
+type shapeGeometry struct {
+	polys []shapePolygon
+	// area contains the parts of the faces covered by any polygon
+	area [6]faceMask
+	// cover contains the parts of the faces which are completely covered
+	cover [6]faceMask
+}
+
+var shapeGeometries [shapeCount][orientationCount]*shapeGeometry
+
+func (f faceDirection) opposite() faceDirection {
+	return f ^ 1
+}
+
+// inPlaneAxes returns the axes spanning the faces of the given direction.
+func (f faceDirection) inPlaneAxes() (u, v int) {
+	a := f.axis()
+	return (a + 1) % 3, (a + 2) % 3
+}
+
+func (f faceDirection) boundary() float32 {
+	return float32(f % 2)
+}
+
+func poly(n mgl.Vec3, verts ...mgl.Vec3) shapePolygon {
+	n = n.Normalize()
+	if verts[1].Sub(verts[0]).Cross(verts[2].Sub(verts[0])).Dot(n) < 0 {
+		for i, j := 0, len(verts)-1; i < j; i, j = i+1, j-1 {
+			verts[i], verts[j] = verts[j], verts[i]
+		}
+	}
+	return shapePolygon{verts: verts, n: n}
+}
+
+// rect creates an axis aligned rectangle facing dir at the given plane.
+func rect(dir faceDirection, plane float32, uLo, vLo, uHi, vHi float32) shapePolygon {
+	u, v := dir.inPlaneAxes()
+	corner := func(cu, cv float32) mgl.Vec3 {
+		var p mgl.Vec3
+		p[dir.axis()] = plane
+		p[u], p[v] = cu, cv
+		return p
+	}
+	return poly(meshingDirections[dir].n,
+		corner(uLo, vLo), corner(uHi, vLo), corner(uHi, vHi), corner(uLo, vHi))
+}
+
+// canonicalShapes contains the polygons of the shapes in North orientation.
+var canonicalShapes = [shapeCount]func() []shapePolygon{
+	ShapeCube: func() []shapePolygon {
+		res := make([]shapePolygon, 0, 6)
+		for f := faceDirection(0); f < faceDirection(6); f++ {
+			res = append(res, rect(f, f.boundary(), 0, 0, 1, 1))
+		}
+		return res
+	},
+	ShapeSlab: func() []shapePolygon {
+		return []shapePolygon{
+			rect(bottom, 0, 0, 0, 1, 1),
+			rect(top, 0.5, 0, 0, 1, 1),
+			rect(left, 0, 0, 0, 0.5, 1),
+			rect(right, 1, 0, 0, 0.5, 1),
+			rect(back, 0, 0, 0, 1, 0.5),
+			rect(front, 1, 0, 0, 1, 0.5),
+		}
+	},
+	ShapeStairs: func() []shapePolygon {
+		return []shapePolygon{
+			rect(bottom, 0, 0, 0, 1, 1),
+			rect(top, 0.5, 0, 0, 0.5, 1),
+			rect(back, 0.5, 0, 0.5, 1, 1),
+			rect(top, 1, 0.5, 0, 1, 1),
+			rect(front, 1, 0, 0, 1, 1),
+			rect(back, 0, 0, 0, 1, 0.5),
+			rect(left, 0, 0, 0, 0.5, 1),
+			rect(left, 0, 0.5, 0.5, 1, 1),
+			rect(right, 1, 0, 0, 0.5, 1),
+			rect(right, 1, 0.5, 0.5, 1, 1),
+		}
+	},
+	ShapeSlope: func() []shapePolygon {
+		return []shapePolygon{
+			rect(bottom, 0, 0, 0, 1, 1),
+			rect(front, 1, 0, 0, 1, 1),
+			poly(mgl.Vec3{0, 1, -1}, mgl.Vec3{0, 0, 0}, mgl.Vec3{1, 0, 0}, mgl.Vec3{1, 1, 1}, mgl.Vec3{0, 1, 1}),
+			poly(mgl.Vec3{-1, 0, 0}, mgl.Vec3{0, 0, 0}, mgl.Vec3{0, 0, 1}, mgl.Vec3{0, 1, 1}),
+			poly(mgl.Vec3{1, 0, 0}, mgl.Vec3{1, 0, 0}, mgl.Vec3{1, 0, 1}, mgl.Vec3{1, 1, 1}),
+		}
+	},
+	ShapeCross: func() []shapePolygon {
+		a := []mgl.Vec3{{0, 0, 0}, {1, 0, 1}, {1, 1, 1}, {0, 1, 0}}
+		b := []mgl.Vec3{{1, 0, 0}, {0, 0, 1}, {0, 1, 1}, {1, 1, 0}}
+		return []shapePolygon{
+			poly(mgl.Vec3{-1, 0, 1}, append([]mgl.Vec3(nil), a...)...),
+			poly(mgl.Vec3{1, 0, -1}, append([]mgl.Vec3(nil), a...)...),
+			poly(mgl.Vec3{-1, 0, -1}, append([]mgl.Vec3(nil), b...)...),
+			poly(mgl.Vec3{1, 0, 1}, append([]mgl.Vec3(nil), b...)...),
+		}
+	},
+}
+
+func init() {
+	for s := ShapeID(0); s < shapeCount; s++ {
+		for o := Orientation(0); o < orientationCount; o++ {
+			shapeGeometries[s][o] = newShapeGeometry(canonicalShapes[s](), o)
+		}
+	}
+}
+
+// orient rotates the polygon around the Y axis and flips it if requested.
+func (p shapePolygon) orient(o Orientation) shapePolygon {
+	res := shapePolygon{
+		verts: make([]mgl.Vec3, len(p.verts)),
+		n:     p.n,
+	}
+	copy(res.verts, p.verts)
+	for i := Orientation(0); i < o&3; i++ {
+		for j, v := range res.verts {
+			res.verts[j] = mgl.Vec3{1 - v.Z(), v.Y(), v.X()}
+		}
+		res.n = mgl.Vec3{-res.n.Z(), res.n.Y(), res.n.X()}
+	}
+	if o&UpsideDown != 0 {
+		cnt := len(res.verts)
+		flipped := make([]mgl.Vec3, cnt)
+		for j, v := range res.verts {
+			flipped[cnt-1-j] = mgl.Vec3{v.X(), 1 - v.Y(), v.Z()}
+		}
+		res.verts = flipped
+		res.n = mgl.Vec3{res.n.X(), -res.n.Y(), res.n.Z()}
+	}
+	for f := faceDirection(0); f < faceDirection(6); f++ {
+		if !res.n.Equals(meshingDirections[f].n) {
+			continue
+		}
+		res.face = f
+		res.boundary = true
+		for _, v := range res.verts {
+			if v[f.axis()] != f.boundary() {
+				res.boundary = false
+			}
+		}
+	}
+	return res
+}
+
+// contains checks if the polygon projected to the given axes contains the point.
+func (p shapePolygon) contains(u, v int, pu, pv float32) bool {
+	pos, neg := false, false
+	for i, a := range p.verts {
+		b := p.verts[(i+1)%len(p.verts)]
+		c := (b[u]-a[u])*(pv-a[v]) - (b[v]-a[v])*(pu-a[u])
+		pos = pos || c > 0
+		neg = neg || c < 0
+	}
+	return !(pos && neg)
+}
+
+func newShapeGeometry(polys []shapePolygon, o Orientation) *shapeGeometry {
+	res := &shapeGeometry{
+		polys: make([]shapePolygon, len(polys)),
+	}
+	for i, p := range polys {
+		res.polys[i] = p.orient(o)
+	}
+
+	const cell = float32(1) / maskResolution
+	const inset = cell / 100
+	for f := faceDirection(0); f < faceDirection(6); f++ {
+		u, v := f.inPlaneAxes()
+		inside := func(pu, pv float32) bool {
+			for _, p := range res.polys {
+				if p.boundary && p.face == f && p.contains(u, v, pu, pv) {
+					return true
+				}
+			}
+			return false
+		}
+		for i := 0; i < maskResolution; i++ {
+			for j := 0; j < maskResolution; j++ {
+				bit := faceMask(1) << uint(i+maskResolution*j)
+				lu, lv := float32(i)*cell, float32(j)*cell
+				if inside(lu+cell/2, lv+cell/2) {
+					res.area[f] |= bit
+				}
+				if inside(lu+inset, lv+inset) && inside(lu+cell-inset, lv+inset) &&
+					inside(lu+inset, lv+cell-inset) && inside(lu+cell-inset, lv+cell-inset) {
+					res.cover[f] |= bit
+				}
+			}
+		}
+	}
+	return res
+}
+
+// shapeOf returns the shape geometry of the voxel or nil for cubes.
+func shapeOf(v Voxel) *shapeGeometry {
+	sv, ok := v.(ShapedVoxel)
+	if !ok || sv.Shape() == ShapeCube || sv.Shape() >= shapeCount {
+		return nil
+	}
+	return shapeGeometries[sv.Shape()][sv.Orientation()%orientationCount]
+}
+
+// faceArea returns the part of the face in the given direction covered by the voxel.
+func faceArea(v Voxel, dir faceDirection) faceMask {
+	if g := shapeOf(v); g != nil {
+		return g.area[dir]
+	}
+	return fullMask
+}
+
+// faceCover returns the part of the face in the given direction which is
+// occluded by the voxel.
+func faceCover(v Voxel, dir faceDirection) faceMask {
+	if !isVoxelSolid(v) {
+		return 0
+	}
+	if g := shapeOf(v); g != nil {
+		return g.cover[dir]
+	}
+	return fullMask
+}
+
+// meshShapedVoxel creates the quads of a shaped voxel. Triangles are emitted
+// as quads with the last vertex duplicated.
+func (ctx *meshContext) meshShapedVoxel(p mgl.Vec3I, vox Voxel) []VertexF {
+	g := shapeOf(vox)
+	pColor, ok := ColorModel.Convert(vox.Color()).(*Color)
+	if g == nil || !ok {
+		return nil
+	}
+	color := *pColor
+	offset := p.Vec3()
+	result := make([]VertexF, 0, len(g.polys)*4)
+	for _, poly := range g.polys {
+		if poly.boundary && !ctx.isFaceVisible(p, vox, poly.face) {
+			continue
+		}
+		for i := 0; i < 4; i++ {
+			v := poly.verts[len(poly.verts)-1]
+			if i < len(poly.verts) {
+				v = poly.verts[i]
+			}
+			result = append(result, VertexF{color, poly.n, v.Add(offset)})
+		}
+	}
+	return result
+}
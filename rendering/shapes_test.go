@@ -0,0 +1,146 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type testShapedVoxel struct {
+	testVoxel
+	shape ShapeID
+	o     Orientation
+}
+
+func (v testShapedVoxel) Shape() ShapeID {
+	return v.shape
+}
+
+func (v testShapedVoxel) Orientation() Orientation {
+	return v.o
+}
+
+func countMask(m faceMask) int {
+	cnt := 0
+	for ; m != 0; m &= m - 1 {
+		cnt++
+	}
+	return cnt
+}
+
+func Test_ShapeMasks(t *testing.T) {
+	slab := shapeGeometries[ShapeSlab][North]
+	if slab.cover[bottom] != fullMask || slab.area[top] != 0 {
+		t.Errorf("Invalid slab masks for top or bottom face")
+	}
+	if countMask(slab.cover[left]) != 8 || slab.cover[left] != slab.area[left] {
+		t.Errorf("Slab should cover half of its left face: %016b", slab.cover[left])
+	}
+
+	stairs := shapeGeometries[ShapeStairs][North]
+	if stairs.cover[front] != fullMask || countMask(stairs.cover[right]) != 12 || countMask(stairs.cover[back]) != 8 {
+		t.Errorf("Invalid stairs masks")
+	}
+	if shapeGeometries[ShapeStairs][East].cover[left] != fullMask {
+		t.Errorf("Rotated stairs should cover their left face")
+	}
+	if shapeGeometries[ShapeSlab][UpsideDown].cover[top] != fullMask {
+		t.Errorf("Upside down slabs should cover their top face")
+	}
+
+	slope := shapeGeometries[ShapeSlope][North]
+	if countMask(slope.cover[left]) != 6 || countMask(slope.area[left]) != 10 || slope.area[top] != 0 {
+		t.Errorf("Invalid slope masks: cover %016b area %016b", slope.cover[left], slope.area[left])
+	}
+
+	cross := shapeGeometries[ShapeCross][North]
+	for f := range cross.cover {
+		if cross.cover[f] != 0 || cross.area[f] != 0 {
+			t.Errorf("Cross should not touch face %v", f)
+		}
+	}
+}
+
+func Test_ShapeWinding(t *testing.T) {
+	for s := ShapeID(0); s < shapeCount; s++ {
+		for o := Orientation(0); o < orientationCount; o++ {
+			for _, p := range shapeGeometries[s][o].polys {
+				w := p.verts[1].Sub(p.verts[0]).Cross(p.verts[2].Sub(p.verts[0]))
+				if w.Dot(p.n) <= 0 {
+					t.Errorf("Polygon %v of shape %v (orientation %v) is not counter-clockwise", p.verts, s, o)
+				}
+			}
+		}
+	}
+}
+
+func Test_ShapeCulling(t *testing.T) {
+	slab := testShapedVoxel{testRed, ShapeSlab, North}
+
+	tc := newTestChunk(mgl.Vec3I{2, 1, 1})
+	tc.voxels[mgl.Vec3I{0, 0, 0}] = slab
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = slab
+	// Two slabs next to each other hide their shared side
+	if q := len(meshOf(tc, Options{})) / 4; q != 10 {
+		t.Errorf("Two slabs should have 10 quads got %v", q)
+	}
+
+	tc.voxels[mgl.Vec3I{1, 0, 0}] = testRed
+	// The cube hides the side of the slab but is only partially occluded by the slab.
+	faces := unitFaces(meshOf(tc, Options{NoMeshing: true}))
+	if _, ok := faces[unitFace{mgl.Vec3I{1, 0, 0}, mgl.Vec3I{-1, 0, 0}}]; !ok {
+		t.Error("Cube face next to the slab should be visible")
+	}
+	if q := len(meshOf(tc, Options{NoMeshing: true})) / 4; q != 5+6 {
+		t.Errorf("Slab next to a cube should have 11 quads got %v", q)
+	}
+
+	tc = newTestChunk(mgl.Vec3I{3, 3, 3})
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			for z := 0; z < 3; z++ {
+				tc.voxels[mgl.Vec3I{x, y, z}] = testRed
+			}
+		}
+	}
+	tc.voxels[mgl.Vec3I{1, 1, 1}] = testShapedVoxel{testBlue, ShapeCross, North}
+	tc.voxels[mgl.Vec3I{1, 2, 1}] = testShapedVoxel{testBlue, ShapeSlope, South}
+	mesh := meshOf(tc, Options{})
+	cross, slope := 0, 0
+	for i := 0; i < len(mesh); i += 4 {
+		if mesh[i].Color == (Color{0, 0, 1, 1}) {
+			center := mesh[i].Pos.Add(mesh[i+1].Pos).Add(mesh[i+2].Pos).Add(mesh[i+3].Pos).Mul(0.25)
+			if center.Y() < 2 {
+				cross++
+			} else {
+				slope++
+			}
+		}
+	}
+	if cross != 4 {
+		t.Errorf("Enclosed cross should still have 4 quads got %v", cross)
+	}
+	// The sides of the slope are hidden by the cubes, the bottom is visible since the cross does not occlude it.
+	if slope != 2 {
+		t.Errorf("Embedded slope should have 2 quads got %v", slope)
+	}
+}
+
+func Test_EditableMeshShapes(t *testing.T) {
+	tc := stairChunk()
+	tc.voxels[mgl.Vec3I{1, 2, 1}] = testShapedVoxel{testBlue, ShapeStairs, West}
+	em := NewEditableMesh(tc, Options{NoMeshing: true})
+	if len(em.Vertices()) != len(meshOf(tc, Options{NoMeshing: true})) {
+		t.Errorf("Editable mesh has %v vertices expected %v", len(em.Vertices()), len(meshOf(tc, Options{NoMeshing: true})))
+	}
+
+	tc.voxels[mgl.Vec3I{0, 1, 1}] = testShapedVoxel{testBlue, ShapeSlab, North}
+	em.Invalidate(mgl.Vec3I{0, 1, 1})
+	em.Update()
+	if len(em.shaped) != 2 {
+		t.Errorf("Expected 2 shaped voxels got %v", len(em.shaped))
+	}
+	if len(em.Vertices()) != len(meshOf(tc, Options{NoMeshing: true})) {
+		t.Errorf("Editable mesh has %v vertices expected %v", len(em.Vertices()), len(meshOf(tc, Options{NoMeshing: true})))
+	}
+}
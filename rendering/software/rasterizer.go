@@ -0,0 +1,254 @@
+// Package software renders meshes without OpenGL.
+package software
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+)
+
+// Rasterizer renders the quads created by rendering.CreateMeshFromChunk to
+// an RGBA image using a z-buffer and a single directional light.
+type Rasterizer struct {
+	img   *image.RGBA
+	depth []float32
+
+	// Light is the direction towards the light.
+	Light mgl.Vec3
+	// Ambient is the brightness of faces turned away from the light.
+	Ambient float32
+	// CullBackFaces skips faces which are not counter-clockwise on screen.
+	CullBackFaces bool
+}
+
+// NewRasterizer creates a rasterizer with a transparent image of the given size.
+func NewRasterizer(width, height int) *Rasterizer {
+	r := &Rasterizer{
+		img:           image.NewRGBA(image.Rect(0, 0, width, height)),
+		depth:         make([]float32, width*height),
+		Light:         mgl.Vec3{0.3, 0.8, 0.5},
+		Ambient:       0.35,
+		CullBackFaces: true,
+	}
+	r.Clear(color.Transparent)
+	return r
+}
+
+// Clear fills the image with the given color and resets the z-buffer.
+func (r *Rasterizer) Clear(c color.Color) {
+	draw.Draw(r.img, r.img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	for i := range r.depth {
+		r.depth[i] = float32(math.Inf(1))
+	}
+}
+
+// Image returns the rendered image.
+func (r *Rasterizer) Image() *image.RGBA {
+	return r.img
+}
+
+// TransformMesh returns a copy of the quads with positions and normals
+// transformed by m. m must not contain a non uniform scale.
+func TransformMesh(quads []rendering.VertexF, m mgl.Mat4) []rendering.VertexF {
+	res := make([]rendering.VertexF, len(quads))
+	for i, v := range quads {
+		res[i] = rendering.VertexF{
+			Color: v.Color,
+			Norm:  m.MulVec4(v.Norm.Vec4(0)).Vec3().Normalize(),
+			Pos:   m.MulVec4(v.Pos.Vec4(1)).Vec3(),
+		}
+	}
+	return res
+}
+
+// DrawMesh renders the quads transformed by the view projection matrix.
+// Triangles stored as quads with a duplicated vertex are supported.
+func (r *Rasterizer) DrawMesh(quads []rendering.VertexF, viewProj mgl.Mat4) {
+	light := r.Light.Normalize()
+	for i := 0; i+3 < len(quads); i += 4 {
+		q := quads[i : i+4]
+		c := r.shade(q[0], light)
+		var clip [4]mgl.Vec4
+		for j, v := range q {
+			clip[j] = viewProj.MulVec4(v.Pos.Vec4(1))
+		}
+		r.drawTriangle([]mgl.Vec4{clip[0], clip[1], clip[2]}, c)
+		r.drawTriangle([]mgl.Vec4{clip[0], clip[2], clip[3]}, c)
+	}
+}
+
+// shade returns the premultiplied color of the face lit by the light.
+func (r *Rasterizer) shade(v rendering.VertexF, light mgl.Vec3) color.RGBA {
+	diffuse := v.Norm.Dot(light)
+	if diffuse < 0 {
+		diffuse = 0
+	}
+	f := r.Ambient + (1-r.Ambient)*diffuse
+	a := clamp(v.Alpha)
+	channel := func(c float32) uint8 {
+		return uint8(clamp(c*f)*a*255 + 0.5)
+	}
+	return color.RGBA{channel(v.Red), channel(v.Green), channel(v.Blue), uint8(a*255 + 0.5)}
+}
+
+func clamp(f float32) float32 {
+	if f > 1 {
+		return 1
+	} else if f < 0 {
+		return 0
+	}
+	return f
+}
+
+// clipNear clips the polygon at the near plane (z >= -w).
+func clipNear(poly []mgl.Vec4) []mgl.Vec4 {
+	const epsilon = 1e-5
+	dist := func(v mgl.Vec4) float32 {
+		return v.Z() + v.W() - epsilon
+	}
+	res := make([]mgl.Vec4, 0, len(poly)+1)
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		da, db := dist(a), dist(b)
+		if da >= 0 {
+			res = append(res, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			t := da / (da - db)
+			res = append(res, a.Add(b.Sub(a).Mul(t)))
+		}
+	}
+	return res
+}
+
+type screenVertex struct {
+	x, y, z float32
+}
+
+func (r *Rasterizer) toScreen(v mgl.Vec4) screenVertex {
+	size := r.img.Bounds().Size()
+	return screenVertex{
+		x: (v.X()/v.W() + 1) / 2 * float32(size.X),
+		y: (1 - v.Y()/v.W()) / 2 * float32(size.Y),
+		z: v.Z() / v.W(),
+	}
+}
+
+func edge(a, b screenVertex, px, py float32) float32 {
+	return (b.x-a.x)*(py-a.y) - (b.y-a.y)*(px-a.x)
+}
+
+// isTopLeft checks if the edge is a top or left edge of a triangle with a
+// positive area. Pixels on those edges belong to the triangle.
+func isTopLeft(a, b screenVertex) bool {
+	return (a.y == b.y && b.x > a.x) || b.y < a.y
+}
+
+func (r *Rasterizer) drawTriangle(clip []mgl.Vec4, c color.RGBA) {
+	poly := clipNear(clip)
+	if len(poly) < 3 {
+		return
+	}
+	verts := make([]screenVertex, len(poly))
+	for i, v := range poly {
+		verts[i] = r.toScreen(v)
+	}
+	for i := 1; i+1 < len(verts); i++ {
+		r.fillTriangle(verts[0], verts[i], verts[i+1], c)
+	}
+}
+
+func (r *Rasterizer) fillTriangle(a, b, c screenVertex, col color.RGBA) {
+	area := edge(a, b, c.x, c.y)
+	if area == 0 {
+		return
+	}
+	// The y axis of the image points down, so counter-clockwise triangles
+	// have a negative area.
+	if area > 0 {
+		if r.CullBackFaces {
+			return
+		}
+	} else {
+		b, c = c, b
+		area = -area
+	}
+
+	bounds := r.img.Bounds()
+	minX := int(math.Floor(float64(fmin(a.x, b.x, c.x))))
+	maxX := int(math.Ceil(float64(fmax(a.x, b.x, c.x))))
+	minY := int(math.Floor(float64(fmin(a.y, b.y, c.y))))
+	maxY := int(math.Ceil(float64(fmax(a.y, b.y, c.y))))
+	if minX < bounds.Min.X {
+		minX = bounds.Min.X
+	}
+	if minY < bounds.Min.Y {
+		minY = bounds.Min.Y
+	}
+	if maxX > bounds.Max.X {
+		maxX = bounds.Max.X
+	}
+	if maxY > bounds.Max.Y {
+		maxY = bounds.Max.Y
+	}
+
+	tlA, tlB, tlC := isTopLeft(b, c), isTopLeft(c, a), isTopLeft(a, b)
+	inside := func(w float32, topLeft bool) bool {
+		return w > 0 || (w == 0 && topLeft)
+	}
+	for y := minY; y < maxY; y++ {
+		py := float32(y) + 0.5
+		for x := minX; x < maxX; x++ {
+			px := float32(x) + 0.5
+			wa, wb, wc := edge(b, c, px, py), edge(c, a, px, py), edge(a, b, px, py)
+			if !inside(wa, tlA) || !inside(wb, tlB) || !inside(wc, tlC) {
+				continue
+			}
+			z := (wa*a.z + wb*b.z + wc*c.z) / area
+			idx := (y-bounds.Min.Y)*bounds.Dx() + (x - bounds.Min.X)
+			if z > 1 || z >= r.depth[idx] {
+				continue
+			}
+			r.depth[idx] = z
+			r.blend(x, y, col)
+		}
+	}
+}
+
+// blend draws the premultiplied color over the pixel at x, y.
+func (r *Rasterizer) blend(x, y int, c color.RGBA) {
+	if c.A == 0xFF {
+		r.img.SetRGBA(x, y, c)
+		return
+	}
+	dst := r.img.RGBAAt(x, y)
+	inv := 0xFF - uint32(c.A)
+	mix := func(s, d uint8) uint8 {
+		return s + uint8(uint32(d)*inv/0xFF)
+	}
+	r.img.SetRGBA(x, y, color.RGBA{mix(c.R, dst.R), mix(c.G, dst.G), mix(c.B, dst.B), mix(c.A, dst.A)})
+}
+
+func fmin(v ...float32) float32 {
+	res := v[0]
+	for _, f := range v[1:] {
+		if f < res {
+			res = f
+		}
+	}
+	return res
+}
+
+func fmax(v ...float32) float32 {
+	res := v[0]
+	for _, f := range v[1:] {
+		if f > res {
+			res = f
+		}
+	}
+	return res
+}
@@ -0,0 +1,168 @@
+package software
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+	"github.com/boombuler/voxel/rendering/renderingtest"
+)
+
+var update = flag.Bool("update", false, "update the golden images")
+
+// ortho maps the box from lo to hi to the clip space looking along -Z.
+func ortho(lo, hi mgl.Vec3) mgl.Mat4 {
+	return mgl.Mat4{
+		2 / (hi[0] - lo[0]), 0, 0, 0,
+		0, 2 / (hi[1] - lo[1]), 0, 0,
+		0, 0, -2 / (hi[2] - lo[2]), 0,
+		-(hi[0] + lo[0]) / (hi[0] - lo[0]), -(hi[1] + lo[1]) / (hi[1] - lo[1]), (hi[2] + lo[2]) / (hi[2] - lo[2]), 1,
+	}
+}
+
+func quad(c rendering.Color, z float32, ccw bool) []rendering.VertexF {
+	n := mgl.Vec3{0, 0, 1}
+	res := []rendering.VertexF{
+		{Color: c, Norm: n, Pos: mgl.Vec3{0, 0, z}},
+		{Color: c, Norm: n, Pos: mgl.Vec3{1, 0, z}},
+		{Color: c, Norm: n, Pos: mgl.Vec3{1, 1, z}},
+		{Color: c, Norm: n, Pos: mgl.Vec3{0, 1, z}},
+	}
+	if !ccw {
+		res[1], res[3] = res[3], res[1]
+	}
+	return res
+}
+
+var (
+	red  = rendering.Color{Red: 1, Alpha: 1}
+	blue = rendering.Color{Blue: 1, Alpha: 1}
+)
+
+func Test_RasterizerQuad(t *testing.T) {
+	r := NewRasterizer(8, 8)
+	r.Light = mgl.Vec3{0, 0, 1}
+	r.DrawMesh(quad(red, 0, true), ortho(mgl.Vec3{-0.5, -0.5, -1}, mgl.Vec3{1.5, 1.5, 1}))
+	img := r.Image()
+	if c := img.RGBAAt(4, 4); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("Expected fully lit red at the center got %v", c)
+	}
+	if c := img.RGBAAt(0, 0); c != (color.RGBA{}) {
+		t.Errorf("Expected transparent corner got %v", c)
+	}
+	cnt := 0
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 {
+			cnt++
+		}
+	}
+	if cnt != 16 {
+		t.Errorf("Quad should cover 16 pixels got %v", cnt)
+	}
+
+	r.Clear(color.Transparent)
+	r.DrawMesh(quad(red, 0, false), ortho(mgl.Vec3{-0.5, -0.5, -1}, mgl.Vec3{1.5, 1.5, 1}))
+	if c := r.Image().RGBAAt(4, 4); c != (color.RGBA{}) {
+		t.Errorf("Back faces should be culled got %v", c)
+	}
+}
+
+func Test_RasterizerDepth(t *testing.T) {
+	proj := ortho(mgl.Vec3{0, 0, -2}, mgl.Vec3{1, 1, 2})
+	for _, front := range []bool{true, false} {
+		r := NewRasterizer(4, 4)
+		r.Light = mgl.Vec3{0, 0, 1}
+		if front {
+			r.DrawMesh(quad(red, 1, true), proj)
+			r.DrawMesh(quad(blue, 0, true), proj)
+		} else {
+			r.DrawMesh(quad(blue, 0, true), proj)
+			r.DrawMesh(quad(red, 1, true), proj)
+		}
+		if c := r.Image().RGBAAt(2, 2); c != (color.RGBA{255, 0, 0, 255}) {
+			t.Errorf("Nearer quad should be visible got %v", c)
+		}
+	}
+}
+
+func Test_RasterizerNearClipping(t *testing.T) {
+	// A perspective projection with the near plane at z = -1 and the camera at the origin.
+	proj := mgl.Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, -1, -1,
+		0, 0, -2, 0,
+	}
+	n := mgl.Vec3{0, 1, 0}
+	floor := []rendering.VertexF{
+		{Color: red, Norm: n, Pos: mgl.Vec3{-1, -1, 1}},
+		{Color: red, Norm: n, Pos: mgl.Vec3{1, -1, 1}},
+		{Color: red, Norm: n, Pos: mgl.Vec3{1, -1, -10}},
+		{Color: red, Norm: n, Pos: mgl.Vec3{-1, -1, -10}},
+	}
+	r := NewRasterizer(16, 16)
+	r.DrawMesh(floor, proj)
+	img := r.Image()
+	if c := img.RGBAAt(8, 15); c.A == 0 {
+		t.Error("Floor in front of the camera should be visible")
+	}
+	if c := img.RGBAAt(8, 2); c.A != 0 {
+		t.Errorf("Sky should be empty got %v", c)
+	}
+}
+
+func stairsImage() *image.RGBA {
+	mesh, _ := rendering.CreateMeshFromChunk(renderingtest.StairChunk(), rendering.Options{})
+	model := mgl.Identity().Rotate(math.Pi/6, mgl.Vec3{1, 0, 0}).Rotate(-math.Pi/4, mgl.Vec3{0, 1, 0}).Translate(-2, -2, -1.5)
+	r := NewRasterizer(64, 64)
+	r.DrawMesh(TransformMesh(mesh, model), ortho(mgl.Vec3{-4, -4, -8}, mgl.Vec3{4, 4, 8}))
+	return r.Image()
+}
+
+func Test_RasterizerGolden(t *testing.T) {
+	img := stairsImage()
+	fn := filepath.Join("testdata", "stairs.png")
+	if *update {
+		f, err := os.Create(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !golden.Bounds().Eq(img.Bounds()) {
+		t.Fatalf("Invalid image size %v expected %v", img.Bounds(), golden.Bounds())
+	}
+	// Allow a few pixels to differ on platforms using fused multiply adds.
+	diff := 0
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if color.RGBAModel.Convert(golden.At(x, y)) != img.RGBAAt(x, y) {
+				diff++
+			}
+		}
+	}
+	if diff > 8 {
+		t.Errorf("%v pixels differ from the golden image", diff)
+	}
+}
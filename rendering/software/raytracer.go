@@ -0,0 +1,215 @@
+package software
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering"
+)
+
+// Camera describes the view of a RayTracer.
+type Camera struct {
+	Eye, Target, Up mgl.Vec3
+	// FOV is the vertical field of view of a perspective camera in degrees.
+	FOV float32
+	// OrthoHeight is the height of the visible area. If it is greater than
+	// zero an orthographic projection is used instead of FOV.
+	OrthoHeight float32
+}
+
+// RayTracer renders chunks by tracing rays through the voxel grid. Shaped
+// voxels are traced as cubes.
+type RayTracer struct {
+	// Sun is the direction towards the sun.
+	Sun mgl.Vec3
+	// Ambient is the brightness of faces which are not lit by the sun.
+	Ambient float32
+	// Shadows enables shadow rays towards the sun.
+	Shadows bool
+	// AmbientOcclusion darkens faces by the voxels next to them.
+	AmbientOcclusion bool
+	// Background is the color of rays which hit no voxel.
+	Background color.Color
+	// TileSize is the width and height of the image tiles which are rendered
+	// by Workers goroutines.
+	TileSize int
+	Workers  int
+}
+
+// NewRayTracer creates a ray tracer with shadows and ambient occlusion
+// enabled and a transparent background.
+func NewRayTracer() *RayTracer {
+	return &RayTracer{
+		Sun:              mgl.Vec3{0.3, 0.8, 0.5},
+		Ambient:          0.35,
+		Shadows:          true,
+		AmbientOcclusion: true,
+		Background:       color.Transparent,
+		TileSize:         32,
+		Workers:          runtime.NumCPU(),
+	}
+}
+
+// Render traces the chunk as seen by the camera. The At method of the chunk
+// is called from multiple goroutines.
+func (rt *RayTracer) Render(c rendering.Chunk, cam Camera, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	tileSize, workers := rt.TileSize, rt.Workers
+	if tileSize <= 0 {
+		tileSize = 32
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	tiles := make(chan image.Rectangle)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tile := range tiles {
+				rt.renderTile(img, tile, c, cam)
+			}
+		}()
+	}
+	for y := 0; y < height; y += tileSize {
+		for x := 0; x < width; x += tileSize {
+			tiles <- image.Rect(x, y, x+tileSize, y+tileSize).Intersect(img.Bounds())
+		}
+	}
+	close(tiles)
+	wg.Wait()
+	return img
+}
+
+func (rt *RayTracer) renderTile(img *image.RGBA, tile image.Rectangle, c rendering.Chunk, cam Camera) {
+	size := img.Bounds().Size()
+	forward := cam.Target.Sub(cam.Eye).Normalize()
+	right := forward.Cross(cam.Up).Normalize()
+	up := right.Cross(forward)
+	aspect := float32(size.X) / float32(size.Y)
+	scale := cam.OrthoHeight / 2
+	if cam.OrthoHeight <= 0 {
+		scale = float32(math.Tan(float64(cam.FOV) * math.Pi / 360))
+	}
+	bg := toPremultiplied(rt.Background)
+	sun := rt.Sun.Normalize()
+
+	for y := tile.Min.Y; y < tile.Max.Y; y++ {
+		for x := tile.Min.X; x < tile.Max.X; x++ {
+			u := (2*(float32(x)+0.5)/float32(size.X) - 1) * aspect * scale
+			v := (1 - 2*(float32(y)+0.5)/float32(size.Y)) * scale
+			offset := right.Mul(u).Add(up.Mul(v))
+			origin, dir := cam.Eye, forward.Add(offset).Normalize()
+			if cam.OrthoHeight > 0 {
+				origin, dir = cam.Eye.Add(offset), forward
+			}
+
+			col := rt.trace(c, origin, dir, sun)
+			col = col.Add(bg.Mul(1 - col.W()))
+			img.SetRGBA(x, y, color.RGBA{
+				uint8(clamp(col[0])*255 + 0.5),
+				uint8(clamp(col[1])*255 + 0.5),
+				uint8(clamp(col[2])*255 + 0.5),
+				uint8(clamp(col[3])*255 + 0.5),
+			})
+		}
+	}
+}
+
+// trace returns the premultiplied color seen along the ray. Translucent
+// voxels are blended front to back.
+func (rt *RayTracer) trace(c rendering.Chunk, origin, dir, sun mgl.Vec3) mgl.Vec4 {
+	var acc mgl.Vec4
+	rendering.WalkVoxels(c, origin, dir, float32(math.Inf(1)), func(h rendering.RaycastHit) bool {
+		if rendering.IsVoxelInvisible(h.Voxel) {
+			return true
+		}
+		src := toPremultiplied(h.Voxel.Color())
+		p := origin.Add(dir.Mul(h.Distance))
+		light := rt.Ambient
+		if rt.AmbientOcclusion {
+			light *= ambientOcclusion(c, h.Pos, h.Normal, p)
+		}
+		if diffuse := h.Normal.Vec3().Dot(sun); diffuse > 0 {
+			if rt.Shadows {
+				diffuse *= transmittance(c, p.Add(h.Normal.Vec3().Mul(1e-3)), sun)
+			}
+			light += (1 - rt.Ambient) * diffuse
+		}
+		src = mgl.Vec4{src[0] * light, src[1] * light, src[2] * light, src[3]}
+		acc = acc.Add(src.Mul(1 - acc.W()))
+		return acc.W() < 0.999
+	})
+	return acc
+}
+
+// transmittance returns the fraction of light passing the voxels along the
+// ray.
+func transmittance(c rendering.Chunk, origin, dir mgl.Vec3) float32 {
+	res := float32(1)
+	rendering.WalkVoxels(c, origin, dir, float32(math.Inf(1)), func(h rendering.RaycastHit) bool {
+		if !rendering.IsVoxelInvisible(h.Voxel) {
+			res *= 1 - toPremultiplied(h.Voxel.Color()).W()
+		}
+		return res > 0.001
+	})
+	return res
+}
+
+// ambientOcclusion returns the brightness of the face at p caused by the
+// voxels next to the face. Each corner of the face is darkened by its two
+// neighbors along the edges and the voxel at the corner and the result is
+// interpolated over the face.
+func ambientOcclusion(c rendering.Chunk, pos, normal mgl.Vec3I, p mgl.Vec3) float32 {
+	axis := 0
+	for i, n := range normal {
+		if n != 0 {
+			axis = i
+		}
+	}
+	u, v := (axis+1)%3, (axis+2)%3
+	var du, dv mgl.Vec3I
+	du[u], dv[v] = 1, 1
+	front := pos.Add(normal)
+	solid := func(q mgl.Vec3I) int {
+		if inChunk(c, q) && !rendering.IsVoxelInvisible(c.At(q)) {
+			return 1
+		}
+		return 0
+	}
+	corner := func(su, sv int) float32 {
+		side1, side2 := solid(front.Add(du.Mul(su))), solid(front.Add(dv.Mul(sv)))
+		if side1 == 1 && side2 == 1 {
+			return 0
+		}
+		occ := side1 + side2 + solid(front.Add(du.Mul(su)).Add(dv.Mul(sv)))
+		return 1 - float32(occ)/3
+	}
+
+	fu := clamp(p[u] - float32(pos[u]))
+	fv := clamp(p[v] - float32(pos[v]))
+	lo := corner(-1, -1)*(1-fu) + corner(1, -1)*fu
+	hi := corner(-1, 1)*(1-fu) + corner(1, 1)*fu
+	return 0.5 + 0.5*(lo*(1-fv)+hi*fv)
+}
+
+func inChunk(c rendering.Chunk, p mgl.Vec3I) bool {
+	size := c.Size()
+	for i := range p {
+		if p[i] < 0 || p[i] >= size[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func toPremultiplied(c color.Color) mgl.Vec4 {
+	r, g, b, a := c.RGBA()
+	return mgl.Vec4{float32(r) / 0xFFFF, float32(g) / 0xFFFF, float32(b) / 0xFFFF, float32(a) / 0xFFFF}
+}
@@ -0,0 +1,137 @@
+package software
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	"github.com/boombuler/voxel/rendering/renderingtest"
+)
+
+// floorChunk returns a white floor of 8x8 voxels with a pillar at its center.
+func floorChunk() *renderingtest.Chunk {
+	tc := renderingtest.NewChunk(mgl.Vec3I{8, 5, 8})
+	for x := 0; x < 8; x++ {
+		for z := 0; z < 8; z++ {
+			tc.Voxels[mgl.Vec3I{x, 0, z}] = renderingtest.White
+		}
+	}
+	for y := 1; y < 5; y++ {
+		tc.Voxels[mgl.Vec3I{4, y, 4}] = renderingtest.White
+	}
+	return tc
+}
+
+// topView looks down on the floor chunk so that every pixel shows one voxel.
+var topView = Camera{
+	Eye:         mgl.Vec3{4, 10, 4},
+	Target:      mgl.Vec3{4, 0, 4},
+	Up:          mgl.Vec3{0, 0, -1},
+	OrthoHeight: 8,
+}
+
+func Test_RayTracerShadows(t *testing.T) {
+	rt := NewRayTracer()
+	rt.AmbientOcclusion = false
+	rt.Sun = mgl.Vec3{1, 1, 0}
+
+	img := rt.Render(floorChunk(), topView, 8, 8)
+	lit, shadow := img.RGBAAt(6, 4), img.RGBAAt(2, 4)
+	if exp := uint8((rt.Ambient+(1-rt.Ambient)*rt.Sun.Normalize()[1])*255 + 0.5); lit.R != exp {
+		t.Errorf("Expected the lit floor to have brightness %d but got %v", exp, lit)
+	}
+	if exp := uint8(rt.Ambient*255 + 0.5); shadow.R != exp {
+		t.Errorf("Expected the shadow of the pillar to have ambient light %d but got %v", exp, shadow)
+	}
+
+	rt.Shadows = false
+	img = rt.Render(floorChunk(), topView, 8, 8)
+	if c := img.RGBAAt(2, 4); c != lit {
+		t.Errorf("Expected no shadow without shadow rays but got %v", c)
+	}
+}
+
+func Test_RayTracerAmbientOcclusion(t *testing.T) {
+	rt := NewRayTracer()
+	rt.Shadows = false
+	rt.Sun = mgl.Vec3{0, -1, 0}
+	rt.Ambient = 1
+
+	img := rt.Render(floorChunk(), topView, 8, 8)
+	open, corner := img.RGBAAt(0, 0), img.RGBAAt(3, 4)
+	if open.R != 255 {
+		t.Errorf("Expected no occlusion far from the pillar but got %v", open)
+	}
+	if corner.R >= open.R {
+		t.Errorf("Expected the floor next to the pillar to be darker but got %v", corner)
+	}
+}
+
+func Test_RayTracerTranslucent(t *testing.T) {
+	tc := renderingtest.NewChunk(mgl.Vec3I{1, 1, 2})
+	tc.Voxels[mgl.Vec3I{0, 0, 1}] = renderingtest.Voxel{B: 128, A: 128}
+	tc.Voxels[mgl.Vec3I{0, 0, 0}] = renderingtest.Red
+
+	rt := NewRayTracer()
+	rt.Ambient = 1
+	rt.AmbientOcclusion = false
+	cam := Camera{Eye: mgl.Vec3{0.5, 0.5, 5}, Target: mgl.Vec3{0.5, 0.5, 0}, Up: mgl.Vec3{0, 1, 0}, FOV: 10}
+	c := rt.Render(tc, cam, 1, 1).RGBAAt(0, 0)
+	if c.A != 255 || c.R < 120 || c.R > 135 || c.B < 120 || c.B > 135 {
+		t.Errorf("Expected red behind half transparent blue but got %v", c)
+	}
+}
+
+func Test_RayTracerTiles(t *testing.T) {
+	cam := Camera{
+		Eye:    mgl.Vec3{12, 10, 14},
+		Target: mgl.Vec3{4, 1, 4},
+		Up:     mgl.Vec3{0, 1, 0},
+		FOV:    45,
+	}
+	rt := NewRayTracer()
+	rt.Background = color.RGBA{0, 0, 64, 255}
+	rt.TileSize, rt.Workers = 100, 1
+	exp := rt.Render(floorChunk(), cam, 50, 40)
+
+	rt.TileSize, rt.Workers = 7, 4
+	got := rt.Render(floorChunk(), cam, 50, 40)
+	for i := range exp.Pix {
+		if exp.Pix[i] != got.Pix[i] {
+			t.Fatalf("Expected tiled rendering to match at byte %d", i)
+		}
+	}
+	if c := got.RGBAAt(0, 0); c != (color.RGBA{0, 0, 64, 255}) {
+		t.Errorf("Expected the background at the corner but got %v", c)
+	}
+}
+
+type noColorVoxel struct{}
+
+func (noColorVoxel) Color() color.Color {
+	return nil
+}
+
+func Test_RayTracerInvisibleVoxels(t *testing.T) {
+	rt := NewRayTracer()
+	rt.Sun = mgl.Vec3{1, 1, 0}
+	tc := floorChunk()
+	for y := 1; y < 5; y++ {
+		delete(tc.Voxels, mgl.Vec3I{4, y, 4})
+	}
+	expected := rt.Render(tc, topView, 8, 8)
+
+	// invisible voxels neither cast shadows nor occlude the floor.
+	for y := 1; y < 5; y++ {
+		tc.Voxels[mgl.Vec3I{4, y, 4}] = noColorVoxel{}
+		tc.Voxels[mgl.Vec3I{3, y, 4}] = renderingtest.Voxel{}
+	}
+	img := rt.Render(tc, topView, 8, 8)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if got, exp := img.RGBAAt(x, y), expected.RGBAAt(x, y); got != exp {
+				t.Fatalf("Pixel %v,%v is %v expected %v", x, y, got, exp)
+			}
+		}
+	}
+}
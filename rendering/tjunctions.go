@@ -0,0 +1,115 @@
+package rendering
+
+import (
+	"sort"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// lineKey identifies an axis aligned line by its axis and the coordinates on
+// the other two axes.
+type lineKey struct {
+	axis int
+	u, v float32
+}
+
+func lineOf(p mgl.Vec3, axis int) lineKey {
+	return lineKey{axis, p[(axis+1)%3], p[(axis+2)%3]}
+}
+
+// edgeAxis returns the axis the edge from a to b is parallel to or -1 if the
+// edge is not axis aligned.
+func edgeAxis(a, b mgl.Vec3) int {
+	axis := -1
+	for i := 0; i < 3; i++ {
+		if a[i] != b[i] {
+			if axis >= 0 {
+				return -1
+			}
+			axis = i
+		}
+	}
+	return axis
+}
+
+// quadPolygon returns the distinct vertices of a quad. Triangles are stored
+// as quads with the last vertex duplicated.
+func quadPolygon(quad []VertexF) []VertexF {
+	if quad[3].Pos.Equals(quad[2].Pos) {
+		return quad[:3]
+	}
+	return quad
+}
+
+// removeTJunctions splits the axis aligned edges of the quads at all vertices
+// of other quads lying on them. Quads with split edges are converted to a fan
+// of triangles around their center.
+func removeTJunctions(quads []VertexF) []VertexF {
+	lines := make(map[lineKey][]float32)
+	seen := make(map[mgl.Vec3]struct{}, len(quads))
+	for _, v := range quads {
+		if _, ok := seen[v.Pos]; ok {
+			continue
+		}
+		seen[v.Pos] = struct{}{}
+		for axis := 0; axis < 3; axis++ {
+			k := lineOf(v.Pos, axis)
+			lines[k] = append(lines[k], v.Pos[axis])
+		}
+	}
+	for _, l := range lines {
+		sort.Sort(float32Slice(l))
+	}
+
+	result := make([]VertexF, 0, len(quads))
+	for i := 0; i+3 < len(quads); i += 4 {
+		poly := quadPolygon(quads[i : i+4])
+		verts := make([]VertexF, 0, len(poly))
+		for j, a := range poly {
+			verts = append(verts, a)
+			b := poly[(j+1)%len(poly)]
+			axis := edgeAxis(a.Pos, b.Pos)
+			if axis < 0 {
+				continue
+			}
+			l := lines[lineOf(a.Pos, axis)]
+			lo, hi := a.Pos[axis], b.Pos[axis]
+			reverse := lo > hi
+			if reverse {
+				lo, hi = hi, lo
+			}
+			start := sort.Search(len(l), func(k int) bool { return l[k] > lo })
+			end := sort.Search(len(l), func(k int) bool { return l[k] >= hi })
+			for k := start; k < end; k++ {
+				t := l[k]
+				if reverse {
+					t = l[start+end-1-k]
+				}
+				p := a.Pos
+				p[axis] = t
+				verts = append(verts, VertexF{a.Color, a.Norm, p})
+			}
+		}
+		if len(verts) == len(poly) {
+			result = append(result, quads[i:i+4]...)
+			continue
+		}
+
+		var center mgl.Vec3
+		for _, v := range poly {
+			center = center.Add(v.Pos)
+		}
+		center = center.Mul(1 / float32(len(poly)))
+		for j, a := range verts {
+			b := verts[(j+1)%len(verts)]
+			result = append(result, VertexF{a.Color, a.Norm, center}, a, b, b)
+		}
+	}
+	return result
+}
+
+type float32Slice []float32
+
+func (s float32Slice) Len() int           { return len(s) }
+func (s float32Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s float32Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
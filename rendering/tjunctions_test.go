@@ -0,0 +1,65 @@
+package rendering
+
+import (
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+type meshEdge struct {
+	a, b mgl.Vec3
+}
+
+// countEdges returns how many faces share each edge of the mesh.
+func countEdges(quads []VertexF) map[meshEdge]int {
+	result := make(map[meshEdge]int)
+	for i := 0; i+3 < len(quads); i += 4 {
+		poly := quadPolygon(quads[i : i+4])
+		for j, v := range poly {
+			a, b := v.Pos, poly[(j+1)%len(poly)].Pos
+			if b[0] < a[0] || (b[0] == a[0] && (b[1] < a[1] || (b[1] == a[1] && b[2] < a[2]))) {
+				a, b = b, a
+			}
+			result[meshEdge{a, b}]++
+		}
+	}
+	return result
+}
+
+func Test_NoTJunctions(t *testing.T) {
+	tc := stairChunk()
+	tc.voxels[mgl.Vec3I{3, 3, 1}] = nil
+
+	edges := countEdges(meshOf(tc, Options{}))
+	broken := 0
+	for _, cnt := range edges {
+		if cnt != 2 {
+			broken++
+		}
+	}
+	if broken == 0 {
+		t.Error("Greedy mesh of the test model should contain T-junctions")
+	}
+
+	mesh := meshOf(tc, Options{NoTJunctions: true})
+	for e, cnt := range countEdges(mesh) {
+		if cnt != 2 {
+			t.Errorf("Edge %v is shared by %v faces", e, cnt)
+		}
+	}
+	for i := 0; i < len(mesh); i += 4 {
+		a, b, c := mesh[i], mesh[i+1], mesh[i+2]
+		if b.Pos.Sub(a.Pos).Cross(c.Pos.Sub(a.Pos)).Dot(a.Norm) <= 0 {
+			t.Errorf("Face %v, %v, %v is not counter-clockwise", a.Pos, b.Pos, c.Pos)
+		}
+	}
+}
+
+func Test_NoTJunctionsKeepsQuads(t *testing.T) {
+	tc := filledChunk(mgl.Vec3I{2, 2, 2}, func(p mgl.Vec3I) Voxel {
+		return testRed
+	})
+	if q := len(meshOf(tc, Options{NoTJunctions: true})) / 4; q != 6 {
+		t.Errorf("Mesh without T-junctions should not be split got %v quads", q)
+	}
+}
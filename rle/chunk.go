@@ -0,0 +1,31 @@
+package rle
+
+import (
+	"io"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+// CompressedChunk is implemented by ChunkData and PackedChunkData.
+type CompressedChunk interface {
+	r.IteratableChunk
+	Set(pos mgl.Vec3I, vox r.Voxel)
+	Uncompress() *UncompressedChunkData
+	// DataSize returns the number of bytes used by the voxels without the
+	// palette.
+	DataSize() int
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// CompressSmallest returns the compressed chunk which needs the fewest bytes
+// for the voxels. Run length encoding wins for sparse chunks while packing is
+// better for noisy chunks.
+func (data *UncompressedChunkData) CompressSmallest() CompressedChunk {
+	c := data.Compress()
+	if p := data.Pack(); p != nil && p.DataSize() < c.DataSize() {
+		return p
+	}
+	return c
+}
@@ -4,15 +4,44 @@ import (
 	"bytes"
 	"github.com/boombuler/voxel/mgl"
 	r "github.com/boombuler/voxel/rendering"
+	"sort"
 )
 
 type ChunkData struct {
 	Palette []r.Voxel
-	buf     *bytes.Buffer
+	size    mgl.Vec3I
+	order   Order
+	// Codec encodes the palette when the chunk is serialized. RGBACodec is
+	// used if it is nil.
+	Codec VoxelCodec
+	buf   *bytes.Buffer
+	// runs indexes the runs in buf by the index of their first voxel.
+	runs []runInfo
+}
+
+type runInfo struct {
+	start int // index of the first voxel of the run
+	off   int // offset of the run in buf
 }
 
 func (c *ChunkData) palIndex(vox r.Voxel) int {
-	for i, v := range c.Palette {
+	return palIndex(&c.Palette, vox)
+}
+
+// palIndex returns the index of the voxel in the palette. The voxel is
+// appended if it is missing.
+func palIndex(palette *[]r.Voxel, vox r.Voxel) int {
+	if i := findVoxel(*palette, vox); i >= 0 {
+		return i
+	}
+	idx := len(*palette)
+	*palette = append(*palette, vox)
+	return idx
+}
+
+// findVoxel returns the index of the voxel in the palette or -1.
+func findVoxel(palette []r.Voxel, vox r.Voxel) int {
+	for i, v := range palette {
 		if v != nil {
 			if v == vox {
 				return i
@@ -21,40 +50,51 @@ func (c *ChunkData) palIndex(vox r.Voxel) int {
 			return i
 		}
 	}
-	idx := len(c.Palette)
-	c.Palette = append(c.Palette, vox)
-	return idx
+	return -1
 }
 
 func (data *UncompressedChunkData) Compress() *ChunkData {
+	return data.CompressOrder(OrderXYZ)
+}
+
+// CompressOrder compresses the voxels in the given order.
+func (data *UncompressedChunkData) CompressOrder(o Order) *ChunkData {
 	result := &ChunkData{
 		Palette: []r.Voxel{},
+		size:    data.size,
+		order:   o,
 		buf:     new(bytes.Buffer),
 	}
 
 	curIdx := -1
 	curCnt := uint(0)
 
-	for _, v := range data {
-		idx := result.palIndex(v)
+	i := 0
+	o.walk(data.size, func(xyz int) {
+		idx := result.palIndex(data.data[xyz])
 		if idx == curIdx {
 			curCnt++
 		} else {
 			if curCnt > 0 {
-				result.buf.Write(codeInt(uint(curIdx)))
-				result.buf.Write(codeInt(curCnt))
+				result.writeRun(i-int(curCnt), curIdx, curCnt)
 			}
 			curCnt = 1
 			curIdx = idx
 		}
-	}
+		i++
+	})
 	if curCnt > 0 {
-		result.buf.Write(codeInt(uint(curIdx)))
-		result.buf.Write(codeInt(curCnt))
+		result.writeRun(len(data.data)-int(curCnt), curIdx, curCnt)
 	}
 	return result
 }
 
+func (c *ChunkData) writeRun(start, idx int, cnt uint) {
+	c.runs = append(c.runs, runInfo{start, c.buf.Len()})
+	c.buf.Write(codeInt(uint(idx)))
+	c.buf.Write(codeInt(cnt))
+}
+
 func (c *ChunkData) iterate(fn func(startIdx, cnt int, vox r.Voxel) bool) {
 	d := c.buf.Bytes()
 	i := 0
@@ -72,13 +112,21 @@ func (c *ChunkData) iterate(fn func(startIdx, cnt int, vox r.Voxel) bool) {
 }
 
 func (c *ChunkData) Uncompress() *UncompressedChunkData {
-	cd := NewUncompressedChunkData()
+	cd := NewUncompressedChunkData(c.size)
 
-	c.iterate(func(i, cnt int, vox r.Voxel) bool {
-		for j := 0; j < cnt; j++ {
-			cd[i+j] = vox
+	d := c.buf.Bytes()
+	var vox r.Voxel
+	left := uint(0)
+	c.order.walk(c.size, func(xyz int) {
+		for left == 0 {
+			idx, n := decodeInt(d)
+			d = d[n:]
+			left, n = decodeInt(d)
+			d = d[n:]
+			vox = c.Palette[idx]
 		}
-		return true
+		cd.data[xyz] = vox
+		left--
 	})
 
 	return cd
@@ -88,7 +136,7 @@ func (c *ChunkData) ForeachVoxel(fn func(pos mgl.Vec3I, vox r.Voxel)) {
 	c.iterate(func(i, cnt int, vox r.Voxel) bool {
 		if vox != nil {
 			for j := 0; j < cnt; j++ {
-				fn(idxToVec(i+j), vox)
+				fn(c.order.idxToVec(c.size, i+j), vox)
 			}
 		}
 		return true
@@ -96,20 +144,123 @@ func (c *ChunkData) ForeachVoxel(fn func(pos mgl.Vec3I, vox r.Voxel)) {
 }
 
 func (c *ChunkData) Size() mgl.Vec3I {
-	return mgl.Vec3I{ChunkSizeX, ChunkSizeY, ChunkSizeZ}
+	return c.size
+}
+
+// Order returns the order of the voxels within the runs.
+func (c *ChunkData) Order() Order {
+	return c.order
+}
+
+// findRun returns the index of the run containing the voxel with the given
+// index.
+func (c *ChunkData) findRun(idx int) int {
+	return sort.Search(len(c.runs), func(i int) bool {
+		return c.runs[i].start > idx
+	}) - 1
+}
+
+// run returns the palette index and the length of the i-th run.
+func (c *ChunkData) run(i int) (idx, cnt int) {
+	pIdx, _ := decodeInt(c.buf.Bytes()[c.runs[i].off:])
+	end := volume(c.size)
+	if i+1 < len(c.runs) {
+		end = c.runs[i+1].start
+	}
+	return int(pIdx), end - c.runs[i].start
 }
 
-// At returns the voxel at the given position. (Warning this very slow!)
+// At returns the voxel at the given position.
 func (c *ChunkData) At(pos mgl.Vec3I) r.Voxel {
-	tIdx := vecToIdx(pos)
+	idx, _ := c.run(c.findRun(c.order.vecToIdx(c.size, pos)))
+	return c.Palette[idx]
+}
 
-	var rVox r.Voxel = nil
-	c.iterate(func(i, cnt int, vox r.Voxel) bool {
-		if i <= tIdx && tIdx < (i+cnt) {
-			rVox = vox
-			return false
+// Set replaces the voxel at the given position by splitting and merging the
+// affected runs. Voxels which are no longer used stay in the palette. Set must
+// not be called concurrently with other methods of the chunk.
+func (c *ChunkData) Set(pos mgl.Vec3I, vox r.Voxel) {
+	tIdx := c.order.vecToIdx(c.size, pos)
+	pIdx := c.palIndex(vox)
+	i := c.findRun(tIdx)
+	curIdx, curCnt := c.run(i)
+	if curIdx == pIdx {
+		return
+	}
+
+	type seg struct{ idx, cnt int }
+	start, end := c.runs[i].start, c.runs[i].start+curCnt
+	segs := []seg{{curIdx, tIdx - start}, {pIdx, 1}, {curIdx, end - tIdx - 1}}
+	first, last := i, i
+	if tIdx == start && i > 0 {
+		if idx, cnt := c.run(i - 1); idx == pIdx {
+			first, start = i-1, start-cnt
+			segs[0] = seg{idx, cnt}
 		}
-		return true
-	})
-	return rVox
+	}
+	if tIdx == end-1 && i+1 < len(c.runs) {
+		if idx, cnt := c.run(i + 1); idx == pIdx {
+			last = i + 1
+			segs[2] = seg{idx, cnt}
+		}
+	}
+
+	// merge neighboring segments of the same voxel.
+	var merged []seg
+	for _, s := range segs {
+		if s.cnt == 0 {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].idx == s.idx {
+			merged[n-1].cnt += s.cnt
+		} else {
+			merged = append(merged, s)
+		}
+	}
+
+	offStart, offEnd := c.runs[first].off, c.buf.Len()
+	if last+1 < len(c.runs) {
+		offEnd = c.runs[last+1].off
+	}
+	var enc []byte
+	runs := make([]runInfo, 0, len(merged))
+	for _, s := range merged {
+		runs = append(runs, runInfo{start, offStart + len(enc)})
+		enc = append(enc, codeInt(uint(s.idx))...)
+		enc = append(enc, codeInt(uint(s.cnt))...)
+		start += s.cnt
+	}
+	c.splice(offStart, offEnd, enc)
+
+	// replace the index entries of the affected runs.
+	oldLen := len(c.runs)
+	grow := len(runs) - (last + 1 - first)
+	if grow > 0 {
+		c.runs = append(c.runs, runs[:grow]...)
+	}
+	copy(c.runs[last+1+grow:], c.runs[last+1:oldLen])
+	copy(c.runs[first:], runs)
+	c.runs = c.runs[:oldLen+grow]
+	delta := len(enc) - (offEnd - offStart)
+	for j := first + len(runs); j < len(c.runs); j++ {
+		c.runs[j].off += delta
+	}
+}
+
+// splice replaces the bytes of buf from from to to by data.
+func (c *ChunkData) splice(from, to int, data []byte) {
+	n := c.buf.Len()
+	grow := len(data) - (to - from)
+	if grow > 0 {
+		c.buf.Write(data[:grow])
+	}
+	d := c.buf.Bytes()
+	copy(d[to+grow:], d[to:n])
+	copy(d[from:], data)
+	c.buf.Truncate(n + grow)
+}
+
+// DataSize returns the number of bytes used by the runs.
+func (c *ChunkData) DataSize() int {
+	return c.buf.Len()
 }
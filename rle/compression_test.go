@@ -10,44 +10,50 @@ import (
 
 type testVoxel int
 
+var testSizes = []mgl.Vec3I{DefaultChunkSize, {16, 16, 16}, {32, 256, 32}, {5, 3, 7}}
+
 func (tv testVoxel) Color() color.Color {
 	return color.Black
 }
 
 func Test_CompressEmpty(t *testing.T) {
-	ucd := NewUncompressedChunkData()
-	c := ucd.Compress()
-	cnt := ChunkSizeX * ChunkSizeY * ChunkSizeZ
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		c := ucd.Compress()
+		cnt := volume(size)
 
-	expected := append([]byte{0}, codeInt(uint(cnt))...)
+		expected := append([]byte{0}, codeInt(uint(cnt))...)
 
-	if bytes.Compare(expected, c.buf.Bytes()) != 0 {
-		t.Errorf("Compression error!\nGot: %v\nExpected:%v", c.buf.Bytes(), expected)
+		if bytes.Compare(expected, c.buf.Bytes()) != 0 {
+			t.Errorf("Compression error!\nGot: %v\nExpected:%v", c.buf.Bytes(), expected)
+		}
 	}
 }
 
 func Test_AtCompressed(t *testing.T) {
-	ucd := NewUncompressedChunkData()
-	defer FreeUncompressedChunkData(&ucd)
-	for x := 0; x < ChunkSizeX; x++ {
-		for y := 0; y < ChunkSizeY; y++ {
-			for z := 0; z < ChunkSizeZ; z++ {
-				val := ((3 * x) + (17 * y) + z) % 3
-				ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(val))
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		defer FreeUncompressedChunkData(&ucd)
+		for x := 0; x < size.X(); x++ {
+			for y := 0; y < size.Y(); y++ {
+				for z := 0; z < size.Z(); z++ {
+					val := ((3 * x) + (17 * y) + z) % 3
+					ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(val))
+				}
 			}
 		}
-	}
-	cd := ucd.Compress()
+		cd := ucd.Compress()
 
-	for x := 0; x < ChunkSizeX; x++ {
-		for y := 0; y < ChunkSizeY; y++ {
-			for z := 0; z < ChunkSizeZ; z++ {
-				pos := mgl.Vec3I{x, y, z}
-				uv := ucd.At(pos)
-				cv := cd.At(pos)
-				if uv != cv {
-					t.Errorf("At failed at position %v. Got %v expected %v", pos, cv, uv)
-					return
+		for x := 0; x < size.X(); x++ {
+			for y := 0; y < size.Y(); y++ {
+				for z := 0; z < size.Z(); z++ {
+					pos := mgl.Vec3I{x, y, z}
+					uv := ucd.At(pos)
+					cv := cd.At(pos)
+					if uv != cv {
+						t.Errorf("At failed at position %v. Got %v expected %v", pos, cv, uv)
+						return
+					}
 				}
 			}
 		}
@@ -57,7 +63,7 @@ func Test_AtCompressed(t *testing.T) {
 func BenchmarkAtCompressed(b *testing.B) {
 	b.StopTimer()
 
-	ucd := NewUncompressedChunkData()
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
 	defer FreeUncompressedChunkData(&ucd)
 	for x := 0; x < ChunkSizeX; x++ {
 		for y := 0; y < ChunkSizeY; y++ {
@@ -71,7 +77,7 @@ func BenchmarkAtCompressed(b *testing.B) {
 	rand.Seed(1337)
 	positions := make([]mgl.Vec3I, 0)
 	for i := 0; i < 10000; i++ {
-		positions = append(positions, idxToVec(int(rand.Int31n(ChunkSizeX*ChunkSizeY*ChunkSizeZ))))
+		positions = append(positions, idxToVec(DefaultChunkSize, int(rand.Int31n(ChunkSizeX*ChunkSizeY*ChunkSizeZ))))
 	}
 
 	for i := 0; i < b.N; i++ {
@@ -84,7 +90,7 @@ func BenchmarkAtCompressed(b *testing.B) {
 
 func BenchmarkCompress(b *testing.B) {
 	b.StopTimer()
-	ucd := NewUncompressedChunkData()
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
 
 	for x := 0; x < ChunkSizeX; x++ {
 		for y := 0; y < ChunkSizeY; y++ {
@@ -100,7 +106,7 @@ func BenchmarkCompress(b *testing.B) {
 		_ = ucd.Compress()
 		b.StopTimer()
 	}
-	ucs := len(*ucd)
+	ucs := len(ucd.data)
 	c := ucd.Compress()
 	cp := (float64(c.buf.Len()) / float64(ucs)) * 100
 	b.Logf("Compressed To: %v bytes --> %v%%", c.buf.Len(), cp)
@@ -108,7 +114,7 @@ func BenchmarkCompress(b *testing.B) {
 
 func BenchmarkDecompress(b *testing.B) {
 	b.StopTimer()
-	ucd := NewUncompressedChunkData()
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
 	for x := 0; x < ChunkSizeX; x++ {
 		for y := 0; y < ChunkSizeY; y++ {
 			for z := 0; z < ChunkSizeZ; z++ {
@@ -129,3 +135,87 @@ func BenchmarkDecompress(b *testing.B) {
 		FreeUncompressedChunkData(&data)
 	}
 }
+
+func Test_SetCompressed(t *testing.T) {
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		defer FreeUncompressedChunkData(&ucd)
+		for x := 0; x < size.X(); x++ {
+			for y := 0; y < size.Y()/2; y++ {
+				for z := 0; z < size.Z(); z++ {
+					ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(z/8))
+				}
+			}
+		}
+		cd := ucd.Compress()
+
+		rnd := rand.New(rand.NewSource(42))
+		for i := 0; i < 20000; i++ {
+			pos := idxToVec(size, rnd.Intn(volume(size)))
+			if i%2 == 0 {
+				// favor the borders of runs to test merging.
+				pos = mgl.Vec3I{rnd.Intn(2) * (size.X() - 1), pos.Y(), pos.Z()}
+			}
+			vox := testVoxel(rnd.Intn(10))
+			if rnd.Intn(4) == 0 {
+				ucd.Set(pos, nil)
+				cd.Set(pos, nil)
+			} else {
+				ucd.Set(pos, vox)
+				cd.Set(pos, vox)
+			}
+		}
+
+		compareChunks(t, cd.Uncompress(), ucd)
+		runs, err := indexRuns(cd.buf.Bytes(), len(cd.Palette), len(ucd.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != len(cd.runs) {
+			t.Fatalf("Index has %v runs, expected %v", len(cd.runs), len(runs))
+		}
+		for i := range runs {
+			if runs[i] != cd.runs[i] {
+				t.Fatalf("Index entry %v is %v expected %v", i, cd.runs[i], runs[i])
+			}
+		}
+		if exp := len(ucd.Compress().runs); len(runs) != exp {
+			t.Errorf("Set left %v runs, compression results in %v runs", len(runs), exp)
+		}
+
+		for x := 0; x < size.X(); x++ {
+			for y := 0; y < size.Y(); y++ {
+				for z := 0; z < size.Z(); z++ {
+					cd.Set(mgl.Vec3I{x, y, z}, testVoxel(1))
+				}
+			}
+		}
+		if len(cd.runs) != 1 {
+			t.Errorf("Filled chunk has %v runs, expected 1", len(cd.runs))
+		}
+	}
+}
+
+func BenchmarkSetCompressed(b *testing.B) {
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
+	defer FreeUncompressedChunkData(&ucd)
+	for x := 0; x < ChunkSizeX; x++ {
+		for y := 0; y < ChunkSizeY; y++ {
+			for z := 0; z < ChunkSizeZ; z++ {
+				val := ((3 * x) + (17 * y) + z) % 3
+				ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(val))
+			}
+		}
+	}
+	cd := ucd.Compress()
+	rand.Seed(1337)
+	positions := make([]mgl.Vec3I, 0)
+	for i := 0; i < 10000; i++ {
+		positions = append(positions, idxToVec(DefaultChunkSize, int(rand.Int31n(ChunkSizeX*ChunkSizeY*ChunkSizeZ))))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cd.Set(positions[i%len(positions)], testVoxel(i%4))
+	}
+}
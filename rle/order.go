@@ -0,0 +1,151 @@
+package rle
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/boombuler/voxel/mgl"
+)
+
+// Order is the order in which ChunkData stores the voxels. The first axis in
+// the name of a linear order changes fastest, so OrderXYZ stores rows along
+// the x axis and OrderYXZ stores vertical columns. Which order compresses
+// best depends on the content of the chunk.
+type Order byte
+
+// The values of the orders are stored in serialized chunks.
+const (
+	OrderXYZ Order = iota
+	OrderXZY
+	OrderYXZ
+	OrderYZX
+	OrderZXY
+	OrderZYX
+	// OrderMorton follows the Z-order curve, which keeps voxels close to
+	// each other in all directions together.
+	OrderMorton
+	orderCount
+)
+
+// orderAxes holds the axes of the linear orders, fastest first.
+var orderAxes = [...][3]int{
+	OrderXYZ: {0, 1, 2},
+	OrderXZY: {0, 2, 1},
+	OrderYXZ: {1, 0, 2},
+	OrderYZX: {1, 2, 0},
+	OrderZXY: {2, 0, 1},
+	OrderZYX: {2, 1, 0},
+}
+
+// Orders returns all orders.
+func Orders() []Order {
+	res := make([]Order, orderCount)
+	for i := range res {
+		res[i] = Order(i)
+	}
+	return res
+}
+
+func (o Order) String() string {
+	if o == OrderMorton {
+		return "Morton"
+	}
+	if o < orderCount {
+		name := ""
+		for _, a := range orderAxes[o] {
+			name += string("XYZ"[a])
+		}
+		return name
+	}
+	return fmt.Sprintf("Order(%d)", byte(o))
+}
+
+// vecToIdx returns the index of the position within the order.
+func (o Order) vecToIdx(size, v mgl.Vec3I) int {
+	if o == OrderMorton {
+		return int(mortonTablesFor(size).toIdx[vecToIdx(size, v)])
+	}
+	a := orderAxes[o]
+	return (((v[a[2]] * size[a[1]]) + v[a[1]]) * size[a[0]]) + v[a[0]]
+}
+
+// idxToVec returns the position of the index within the order.
+func (o Order) idxToVec(size mgl.Vec3I, i int) mgl.Vec3I {
+	if o == OrderMorton {
+		return idxToVec(size, int(mortonTablesFor(size).toPos[i]))
+	}
+	a := orderAxes[o]
+	var v mgl.Vec3I
+	v[a[0]] = i % size[a[0]]
+	i /= size[a[0]]
+	v[a[1]] = i % size[a[1]]
+	v[a[2]] = i / size[a[1]]
+	return v
+}
+
+// walk calls fn with the index within OrderXYZ of every position in the
+// order.
+func (o Order) walk(size mgl.Vec3I, fn func(xyz int)) {
+	if o == OrderMorton {
+		for _, pos := range mortonTablesFor(size).toPos {
+			fn(int(pos))
+		}
+		return
+	}
+	a := orderAxes[o]
+	stride := mgl.Vec3I{1, size.X(), size.X() * size.Y()}
+	for k := 0; k < size[a[2]]; k++ {
+		for j := 0; j < size[a[1]]; j++ {
+			base := k*stride[a[2]] + j*stride[a[1]]
+			for i := 0; i < size[a[0]]; i++ {
+				fn(base + i*stride[a[0]])
+			}
+		}
+	}
+}
+
+// mortonTables map the indices of OrderXYZ to the indices of OrderMorton and
+// back. Chunks whose size is no power of two skip the positions of the curve
+// outside of the chunk.
+type mortonTables struct {
+	toIdx, toPos []int32
+}
+
+var mortonTableCache sync.Map
+
+func mortonTablesFor(size mgl.Vec3I) *mortonTables {
+	if t, ok := mortonTableCache.Load(size); ok {
+		return t.(*mortonTables)
+	}
+	n := volume(size)
+	codes := make([]uint64, n)
+	t := &mortonTables{
+		toIdx: make([]int32, n),
+		toPos: make([]int32, n),
+	}
+	for i := range codes {
+		codes[i] = mortonCode(idxToVec(size, i))
+		t.toPos[i] = int32(i)
+	}
+	sort.Slice(t.toPos, func(i, j int) bool {
+		return codes[t.toPos[i]] < codes[t.toPos[j]]
+	})
+	for idx, pos := range t.toPos {
+		t.toIdx[pos] = int32(idx)
+	}
+	res, _ := mortonTableCache.LoadOrStore(size, t)
+	return res.(*mortonTables)
+}
+
+// mortonCode interleaves the bits of the coordinates, starting with the
+// lowest bit of x.
+func mortonCode(v mgl.Vec3I) uint64 {
+	var code uint64
+	for bit := uint(0); bit < 21; bit++ {
+		for axis := uint(0); axis < 3; axis++ {
+			code |= uint64(v[axis]>>bit&1) << (3*bit + axis)
+		}
+	}
+	return code
+}
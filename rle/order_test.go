@@ -0,0 +1,171 @@
+package rle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+func Test_OrderString(t *testing.T) {
+	tests := map[Order]string{
+		OrderXYZ:    "XYZ",
+		OrderYXZ:    "YXZ",
+		OrderZYX:    "ZYX",
+		OrderMorton: "Morton",
+		orderCount:  "Order(7)",
+	}
+	for o, exp := range tests {
+		if s := o.String(); s != exp {
+			t.Errorf("Order %d is named %q expected %q", byte(o), s, exp)
+		}
+	}
+}
+
+func Test_OrderIndices(t *testing.T) {
+	for _, size := range testSizes {
+		for _, o := range Orders() {
+			seen := make([]bool, volume(size))
+			for i := range seen {
+				pos := o.idxToVec(size, i)
+				if !(mgl.AABBI{Max: size}).Contains(pos) {
+					t.Fatalf("%v: index %v maps to %v outside of %v", o, i, pos, size)
+				}
+				if idx := o.vecToIdx(size, pos); idx != i {
+					t.Fatalf("%v: position %v maps to %v expected %v", o, pos, idx, i)
+				}
+				if xyz := vecToIdx(size, pos); seen[xyz] {
+					t.Fatalf("%v: position %v is visited twice", o, pos)
+				} else {
+					seen[xyz] = true
+				}
+			}
+		}
+	}
+
+	// the z-order curve visits the octants of a cube one after another.
+	size := mgl.Vec3I{4, 4, 4}
+	if pos := OrderMorton.idxToVec(size, 7); !pos.Equals(mgl.Vec3I{1, 1, 1}) {
+		t.Errorf("Morton index 7 is %v expected (1, 1, 1)", pos)
+	}
+	if idx := OrderMorton.vecToIdx(size, mgl.Vec3I{0, 0, 2}); idx != 32 {
+		t.Errorf("Morton index of (0, 0, 2) is %v expected 32", idx)
+	}
+}
+
+func Test_CompressOrder(t *testing.T) {
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		for x := 0; x < size.X(); x++ {
+			for y := 0; y < size.Y(); y++ {
+				for z := 0; z < size.Z(); z++ {
+					var vox r.Voxel
+					if y < 2*x+z {
+						vox = testVoxel((x / 4) % 3)
+					}
+					ucd.Set(mgl.Vec3I{x, y, z}, vox)
+				}
+			}
+		}
+
+		for _, o := range Orders() {
+			cd := ucd.CompressOrder(o)
+			cd.Codec = testCodec
+			if cd.Order() != o {
+				t.Errorf("Chunk has order %v expected %v", cd.Order(), o)
+			}
+			compareChunks(t, cd, ucd)
+			compareChunks(t, cd.Uncompress(), ucd)
+
+			cnt := 0
+			cd.ForeachVoxel(func(pos mgl.Vec3I, vox r.Voxel) {
+				if ucd.At(pos) != vox {
+					t.Fatalf("%v: ForeachVoxel returned %v at %v expected %v", o, vox, pos, ucd.At(pos))
+				}
+				cnt++
+			})
+			ucd.ForeachVoxel(func(pos mgl.Vec3I, vox r.Voxel) {
+				cnt--
+			})
+			if cnt != 0 {
+				t.Errorf("%v: ForeachVoxel returned %v voxels too many", o, cnt)
+			}
+
+			pos := size.Sub(mgl.Vec3I{1, 1, 1})
+			cd.Set(pos, testVoxel(7))
+			if v := cd.At(pos); v != testVoxel(7) {
+				t.Errorf("%v: Set stored %v expected %v", o, v, testVoxel(7))
+			}
+
+			buf := new(bytes.Buffer)
+			if _, err := cd.WriteTo(buf); err != nil {
+				t.Fatal(err)
+			}
+			res := &ChunkData{Codec: testCodec}
+			if _, err := res.ReadFrom(buf); err != nil {
+				t.Fatal(err)
+			}
+			if res.Order() != o {
+				t.Errorf("Read chunk has order %v expected %v", res.Order(), o)
+			}
+			compareChunks(t, res, cd)
+		}
+		FreeUncompressedChunkData(&ucd)
+	}
+}
+
+func Test_ReadVersion1(t *testing.T) {
+	cd := testChunk(mgl.Vec3I{8, 8, 8}, func(pos mgl.Vec3I) r.Voxel {
+		return testVoxel(pos.Y() / 3)
+	})
+	cd.Codec = testCodec
+	buf := new(bytes.Buffer)
+	if _, err := cd.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// version 1 had no order after the size.
+	data := buf.Bytes()
+	orderOff := len(rleMagic) + 2 + 3
+	v1 := append(append([]byte(nil), data[:orderOff]...), data[orderOff+1:len(data)-4]...)
+	v1[len(rleMagic)] = 1
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(v1))
+	v1 = append(v1, sum[:]...)
+
+	res := &ChunkData{Codec: testCodec}
+	if _, err := res.ReadFrom(bytes.NewReader(v1)); err != nil {
+		t.Fatal(err)
+	}
+	if res.Order() != OrderXYZ {
+		t.Errorf("Version 1 chunk has order %v", res.Order())
+	}
+	compareChunks(t, res, cd)
+}
+
+func BenchmarkCompressOrders(b *testing.B) {
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
+	defer FreeUncompressedChunkData(&ucd)
+	for x := 0; x < ChunkSizeX; x++ {
+		for y := 0; y < ChunkSizeY; y++ {
+			for z := 0; z < ChunkSizeZ; z++ {
+				val := ((3 * x) + (17 * y) + z) % 3
+				ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(val))
+			}
+		}
+	}
+
+	for _, o := range Orders() {
+		b.Run(o.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = ucd.CompressOrder(o)
+			}
+			c := ucd.CompressOrder(o)
+			cp := (float64(c.buf.Len()) / float64(len(ucd.data))) * 100
+			b.Logf("Compressed To: %v bytes --> %v%%", c.buf.Len(), cp)
+		})
+	}
+}
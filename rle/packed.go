@@ -0,0 +1,223 @@
+package rle
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+// maxIndexBits is the largest width of the palette indices of chunks created
+// by Pack. Set widens the indices further if a chunk needs it.
+const maxIndexBits = 16
+
+// PackedChunkData stores the voxels as indices into a palette. The indices
+// use as few bits as the palette allows and are packed into 64 bit words
+// without crossing word boundaries. Unlike ChunkData it needs the same space
+// no matter how noisy the chunk is.
+type PackedChunkData struct {
+	Palette []r.Voxel
+	// Codec encodes the palette when the chunk is serialized. RGBACodec is
+	// used if it is nil.
+	Codec VoxelCodec
+	size  mgl.Vec3I
+	bits  uint
+	words []uint64
+}
+
+// indexBits returns the number of bits needed for the indices of a palette
+// with n voxels.
+func indexBits(n int) uint {
+	if n <= 2 {
+		return 1
+	}
+	return uint(bits.Len(uint(n - 1)))
+}
+
+func wordCount(voxels int, bits uint) int {
+	perWord := 64 / int(bits)
+	return (voxels + perWord - 1) / perWord
+}
+
+// Pack packs the chunk. It returns nil if the chunk contains more than 65536
+// different voxels.
+func (data *UncompressedChunkData) Pack() *PackedChunkData {
+	result := &PackedChunkData{size: data.size}
+	lookup := make(map[r.Voxel]int)
+	for _, v := range data.data {
+		if _, ok := lookup[v]; !ok {
+			lookup[v] = len(result.Palette)
+			result.Palette = append(result.Palette, v)
+		}
+	}
+	if len(result.Palette) > 1<<maxIndexBits {
+		return nil
+	}
+
+	result.bits = indexBits(len(result.Palette))
+	result.words = make([]uint64, wordCount(len(data.data), result.bits))
+	for i, v := range data.data {
+		result.put(i, lookup[v])
+	}
+	return result
+}
+
+func (c *PackedChunkData) get(i int) int {
+	perWord := 64 / int(c.bits)
+	shift := uint(i%perWord) * c.bits
+	return int((c.words[i/perWord] >> shift) & (1<<c.bits - 1))
+}
+
+func (c *PackedChunkData) put(i, idx int) {
+	perWord := 64 / int(c.bits)
+	shift := uint(i%perWord) * c.bits
+	w := &c.words[i/perWord]
+	*w = *w&^((1<<c.bits-1)<<shift) | uint64(idx)<<shift
+}
+
+// repack changes the width of the indices.
+func (c *PackedChunkData) repack(bits uint) {
+	old := *c
+	c.bits = bits
+	c.words = make([]uint64, wordCount(volume(c.size), bits))
+	for i := 0; i < volume(c.size); i++ {
+		c.put(i, old.get(i))
+	}
+}
+
+// compacted returns the palette of the voxels in use and the indices packed
+// for this palette. The indices leave room for a palette of factor times the
+// voxels in use. The voxel at index skip is not taken into account.
+func (c *PackedChunkData) compacted(skip, factor int) PackedChunkData {
+	n := volume(c.size)
+	mapping := make([]int, len(c.Palette))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	res := PackedChunkData{Codec: c.Codec, size: c.size}
+	for i := 0; i < n; i++ {
+		if idx := c.get(i); i != skip && mapping[idx] < 0 {
+			mapping[idx] = len(res.Palette)
+			res.Palette = append(res.Palette, c.Palette[idx])
+		}
+	}
+
+	res.bits = indexBits(factor * len(res.Palette))
+	res.words = make([]uint64, wordCount(n, res.bits))
+	for i := 0; i < n; i++ {
+		if i != skip {
+			res.put(i, mapping[c.get(i)])
+		}
+	}
+	return res
+}
+
+func (c *PackedChunkData) Size() mgl.Vec3I {
+	return c.size
+}
+
+func (c *PackedChunkData) At(pos mgl.Vec3I) r.Voxel {
+	return c.Palette[c.get(vecToIdx(c.size, pos))]
+}
+
+// Set replaces the voxel at the given position. If the palette is full, the
+// voxels which are no longer used are removed from it before the indices are
+// widened.
+func (c *PackedChunkData) Set(pos mgl.Vec3I, vox r.Voxel) {
+	i := vecToIdx(c.size, pos)
+	idx := findVoxel(c.Palette, vox)
+	if idx < 0 {
+		if len(c.Palette) >= 1<<c.bits {
+			// leave room for as many new voxels as are in use, so the palette
+			// is not compacted on every Set.
+			*c = c.compacted(i, 2)
+		}
+		idx = len(c.Palette)
+		c.Palette = append(c.Palette, vox)
+		if idx >= 1<<c.bits {
+			c.repack(indexBits(len(c.Palette)))
+		}
+	}
+	c.put(i, idx)
+}
+
+func (c *PackedChunkData) ForeachVoxel(fn func(pos mgl.Vec3I, vox r.Voxel)) {
+	for i, n := 0, volume(c.size); i < n; i++ {
+		if vox := c.Palette[c.get(i)]; vox != nil {
+			fn(idxToVec(c.size, i), vox)
+		}
+	}
+}
+
+func (c *PackedChunkData) Uncompress() *UncompressedChunkData {
+	cd := NewUncompressedChunkData(c.size)
+	for i := range cd.data {
+		cd.data[i] = c.Palette[c.get(i)]
+	}
+	return cd
+}
+
+// DataSize returns the number of bytes used by the indices.
+func (c *PackedChunkData) DataSize() int {
+	return len(c.words) * 8
+}
+
+// WriteTo writes the chunk to w using the codec of the chunk. Palette entries
+// which are not used by any voxel are not written.
+func (c *PackedChunkData) WriteTo(w io.Writer) (int64, error) {
+	cd := c.compacted(-1, 1)
+	data := make([]byte, 1+8*len(cd.words))
+	data[0] = byte(cd.bits)
+	for i, word := range cd.words {
+		binary.LittleEndian.PutUint64(data[1+8*i:], word)
+	}
+	return writeChunk(w, codecOrDefault(c.Codec), serialized{packedMagic, c.size, OrderXYZ, cd.Palette, data})
+}
+
+// ReadFrom replaces the chunk by a chunk read from rd like the ReadFrom method
+// of ChunkData.
+func (c *PackedChunkData) ReadFrom(rd io.Reader) (int64, error) {
+	s, n, err := readChunk(rd, codecOrDefault(c.Codec))
+	if err == nil {
+		err = c.load(s)
+	}
+	return n, err
+}
+
+func (c *PackedChunkData) load(s serialized) error {
+	if s.magic != packedMagic {
+		return errors.New("rle: chunk is not packed")
+	}
+	if s.order != OrderXYZ {
+		return errors.New("rle: invalid order of packed chunk")
+	}
+	// Set may widen the indices beyond maxIndexBits.
+	if len(s.data) == 0 || s.data[0] == 0 || s.data[0] > 32 {
+		return errors.New("rle: invalid index width")
+	}
+	res := PackedChunkData{
+		Palette: s.palette,
+		Codec:   c.Codec,
+		size:    s.size,
+		bits:    uint(s.data[0]),
+	}
+	voxels := volume(s.size)
+	words := s.data[1:]
+	if len(words) != 8*wordCount(voxels, res.bits) {
+		return errors.New("rle: invalid data size")
+	}
+	res.words = make([]uint64, len(words)/8)
+	for i := range res.words {
+		res.words[i] = binary.LittleEndian.Uint64(words[8*i:])
+	}
+	for i := 0; i < voxels; i++ {
+		if res.get(i) >= len(res.Palette) {
+			return errors.New("rle: invalid palette index")
+		}
+	}
+	*c = res
+	return nil
+}
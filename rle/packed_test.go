@@ -0,0 +1,198 @@
+package rle
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+func Test_IndexBits(t *testing.T) {
+	tests := []struct {
+		n    int
+		bits uint
+	}{
+		{1, 1}, {2, 1}, {3, 2}, {4, 2}, {5, 3}, {16, 4}, {17, 5}, {256, 8}, {65536, 16},
+	}
+	for _, tst := range tests {
+		if b := indexBits(tst.n); b != tst.bits {
+			t.Errorf("indexBits(%v) is %v expected %v", tst.n, b, tst.bits)
+		}
+	}
+}
+
+func Test_Pack(t *testing.T) {
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		rnd := rand.New(rand.NewSource(7))
+		for i := range ucd.data {
+			if v := rnd.Intn(12); v < 10 {
+				ucd.data[i] = testVoxel(v)
+			} else {
+				ucd.data[i] = nil
+			}
+		}
+		p := ucd.Pack()
+		if p.bits != 4 {
+			t.Errorf("Packed with %v bits expected 4", p.bits)
+		}
+		compareChunks(t, p, ucd)
+		compareChunks(t, p.Uncompress(), ucd)
+
+		cnt := 0
+		p.ForeachVoxel(func(pos mgl.Vec3I, vox r.Voxel) {
+			if ucd.At(pos) != vox {
+				t.Fatalf("ForeachVoxel returned %v at %v expected %v", vox, pos, ucd.At(pos))
+			}
+			cnt++
+		})
+		ucd.ForeachVoxel(func(pos mgl.Vec3I, vox r.Voxel) {
+			cnt--
+		})
+		if cnt != 0 {
+			t.Errorf("ForeachVoxel returned %v voxels too many", cnt)
+		}
+		FreeUncompressedChunkData(&ucd)
+	}
+}
+
+func Test_SetPacked(t *testing.T) {
+	size := mgl.Vec3I{16, 16, 16}
+	ucd := NewUncompressedChunkData(size)
+	defer FreeUncompressedChunkData(&ucd)
+	for i := range ucd.data {
+		ucd.data[i] = testVoxel(i % 2)
+	}
+	p := ucd.Pack()
+	if p.bits != 1 {
+		t.Fatalf("Packed with %v bits expected 1", p.bits)
+	}
+
+	rnd := rand.New(rand.NewSource(3))
+	for i := 0; i < 5000; i++ {
+		pos := idxToVec(size, rnd.Intn(len(ucd.data)))
+		vox := testVoxel(rnd.Intn(300))
+		ucd.Set(pos, vox)
+		p.Set(pos, vox)
+	}
+	if p.bits < 9 || p.bits > 10 {
+		t.Errorf("Set widened the indices to %v bits expected 9 or 10", p.bits)
+	}
+	compareChunks(t, p, ucd)
+}
+
+func Test_SetPackedCompacts(t *testing.T) {
+	size := mgl.Vec3I{2, 2, 2}
+	ucd := NewUncompressedChunkData(size)
+	defer FreeUncompressedChunkData(&ucd)
+	for i := range ucd.data {
+		ucd.data[i] = nil
+	}
+	p := ucd.Pack()
+	p.Codec = testCodec
+
+	// more voxels than 16 bit indices can address are set over time.
+	for i := 0; i < 70000; i++ {
+		pos := idxToVec(size, i%len(ucd.data))
+		ucd.Set(pos, testVoxel(i))
+		p.Set(pos, testVoxel(i))
+	}
+	if len(p.Palette) > 2*len(ucd.data) {
+		t.Errorf("Palette has %v voxels, only %v are used", len(p.Palette), len(ucd.data))
+	}
+	compareChunks(t, p, ucd)
+
+	buf := new(bytes.Buffer)
+	if _, err := p.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	res := &PackedChunkData{Codec: testCodec}
+	if _, err := res.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Palette) != len(ucd.data) {
+		t.Errorf("Read palette has %v voxels expected %v", len(res.Palette), len(ucd.data))
+	}
+	compareChunks(t, res, ucd)
+}
+
+func Test_SerializePacked(t *testing.T) {
+	for _, size := range testSizes {
+		ucd := NewUncompressedChunkData(size)
+		for i := range ucd.data {
+			ucd.data[i] = testVoxel(i % 5)
+		}
+		p := ucd.Pack()
+		p.Codec = testCodec
+		FreeUncompressedChunkData(&ucd)
+
+		buf := new(bytes.Buffer)
+		if _, err := p.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+		res, err := ReadChunk(bytes.NewReader(buf.Bytes()), testCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := res.(*PackedChunkData); !ok {
+			t.Fatalf("ReadChunk returned %T", res)
+		}
+		compareChunks(t, res, p)
+
+		if _, err := (&ChunkData{Codec: testCodec}).ReadFrom(bytes.NewReader(buf.Bytes())); err == nil {
+			t.Error("Expected error reading a packed chunk as ChunkData")
+		}
+	}
+}
+
+func Test_CompressSmallest(t *testing.T) {
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
+	defer FreeUncompressedChunkData(&ucd)
+	for i := range ucd.data {
+		ucd.data[i] = nil
+		if i < len(ucd.data)/4 {
+			ucd.data[i] = testVoxel(1)
+		}
+	}
+	if c, ok := ucd.CompressSmallest().(*ChunkData); !ok {
+		t.Errorf("Sparse chunk was stored as %T", c)
+	}
+
+	rnd := rand.New(rand.NewSource(5))
+	for i := range ucd.data {
+		ucd.data[i] = testVoxel(rnd.Intn(200))
+	}
+	c := ucd.CompressSmallest()
+	if _, ok := c.(*PackedChunkData); !ok {
+		t.Errorf("Noisy chunk was stored as %T", c)
+	}
+	if rle := ucd.Compress(); c.DataSize() >= rle.DataSize() {
+		t.Errorf("Packed chunk uses %v bytes, run length encoding %v bytes", c.DataSize(), rle.DataSize())
+	}
+}
+
+func BenchmarkAtPacked(b *testing.B) {
+	ucd := NewUncompressedChunkData(DefaultChunkSize)
+	defer FreeUncompressedChunkData(&ucd)
+	for x := 0; x < ChunkSizeX; x++ {
+		for y := 0; y < ChunkSizeY; y++ {
+			for z := 0; z < ChunkSizeZ; z++ {
+				val := ((3 * x) + (17 * y) + z) % 3
+				ucd.Set(mgl.Vec3I{x, y, z}, testVoxel(val))
+			}
+		}
+	}
+	p := ucd.Pack()
+	rand.Seed(1337)
+	positions := make([]mgl.Vec3I, 0)
+	for i := 0; i < 10000; i++ {
+		positions = append(positions, idxToVec(DefaultChunkSize, int(rand.Int31n(ChunkSizeX*ChunkSizeY*ChunkSizeZ))))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = p.At(positions[i%len(positions)])
+	}
+}
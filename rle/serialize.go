@@ -0,0 +1,386 @@
+package rle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"image/color"
+	"io"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+// Serialized chunks start with the magic of the storage and the format
+// version followed by the id of the voxel codec, the chunk size and the order
+// of the voxels. The palette and the voxel data are followed by the CRC32
+// (IEEE) of all previous bytes. Version 1 had no order and stored the voxels
+// in OrderXYZ.
+const (
+	rleMagic    = "RLEC"
+	packedMagic = "PALC"
+	fVersion    = 2
+	// maxVolume limits the size of chunks read by ReadFrom.
+	maxVolume = 1 << 24
+)
+
+// ErrChecksum is returned by ReadFrom if the data was corrupted.
+var ErrChecksum = errors.New("rle: checksum mismatch")
+
+// VoxelCodec encodes the voxels of the palette of serialized chunks. Nil
+// voxels are never passed to the codec.
+type VoxelCodec interface {
+	// CodecID identifies the codec in the serialized data.
+	CodecID() byte
+	Encode(w io.Writer, vox r.Voxel) error
+	// Decode reads a voxel written by Encode.
+	Decode(rd io.Reader) (r.Voxel, error)
+}
+
+// RGBAVoxel is a voxel which consists of nothing but its color.
+type RGBAVoxel color.NRGBA
+
+func (v RGBAVoxel) Color() color.Color {
+	return color.NRGBA(v)
+}
+
+// RGBACodec stores the color of the voxels. The voxels are decoded as
+// RGBAVoxel.
+type RGBACodec struct{}
+
+func (RGBACodec) CodecID() byte {
+	return 1
+}
+
+func (RGBACodec) Encode(w io.Writer, vox r.Voxel) error {
+	c := color.NRGBAModel.Convert(vox.Color()).(color.NRGBA)
+	_, err := w.Write([]byte{c.R, c.G, c.B, c.A})
+	return err
+}
+
+func (RGBACodec) Decode(rd io.Reader) (r.Voxel, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(rd, b[:]); err != nil {
+		return nil, err
+	}
+	return RGBAVoxel{b[0], b[1], b[2], b[3]}, nil
+}
+
+// NumericCodec stores the voxels by a numeric id.
+type NumericCodec struct {
+	ToID   func(vox r.Voxel) uint
+	FromID func(id uint) r.Voxel
+}
+
+func (NumericCodec) CodecID() byte {
+	return 2
+}
+
+func (nc NumericCodec) Encode(w io.Writer, vox r.Voxel) error {
+	_, err := w.Write(codeInt(nc.ToID(vox)))
+	return err
+}
+
+func (nc NumericCodec) Decode(rd io.Reader) (r.Voxel, error) {
+	id, err := binary.ReadUvarint(asByteReader(rd))
+	if err != nil {
+		return nil, err
+	}
+	return nc.FromID(uint(id)), nil
+}
+
+func codecOrDefault(codec VoxelCodec) VoxelCodec {
+	if codec == nil {
+		return RGBACodec{}
+	}
+	return codec
+}
+
+// WriteTo writes the chunk to w using the codec of the chunk. Palette entries
+// which are not used by any run are not written.
+func (c *ChunkData) WriteTo(w io.Writer) (int64, error) {
+	palette, data := c.compacted()
+	return writeChunk(w, codecOrDefault(c.Codec), serialized{rleMagic, c.size, c.order, palette, data})
+}
+
+// compacted returns the palette of the voxels used by the runs in the order
+// of their first run and the runs renumbered for this palette.
+func (c *ChunkData) compacted() ([]r.Voxel, []byte) {
+	var palette []r.Voxel
+	mapping := make(map[int]int)
+	data := new(bytes.Buffer)
+	d := c.buf.Bytes()
+	for len(d) > 0 {
+		idx, n := decodeInt(d)
+		d = d[n:]
+		cnt, n := decodeInt(d)
+		d = d[n:]
+		newIdx, ok := mapping[int(idx)]
+		if !ok {
+			newIdx = len(palette)
+			mapping[int(idx)] = newIdx
+			palette = append(palette, c.Palette[idx])
+		}
+		data.Write(codeInt(uint(newIdx)))
+		data.Write(codeInt(cnt))
+	}
+	return palette, data.Bytes()
+}
+
+// ReadFrom replaces the chunk by a chunk read from rd. The codec of the chunk
+// has to match the codec the data was written with and the chunk takes the
+// size of the serialized chunk. ReadFrom reads no more than the serialized
+// chunk, so several chunks can be read from one stream. io.EOF is returned if
+// rd contains no more data.
+func (c *ChunkData) ReadFrom(rd io.Reader) (int64, error) {
+	s, n, err := readChunk(rd, codecOrDefault(c.Codec))
+	if err == nil {
+		err = c.load(s)
+	}
+	return n, err
+}
+
+func (c *ChunkData) load(s serialized) error {
+	if s.magic != rleMagic {
+		return errors.New("rle: chunk is not run length encoded")
+	}
+	runs, err := indexRuns(s.data, len(s.palette), volume(s.size))
+	if err != nil {
+		return err
+	}
+	c.Palette = s.palette
+	c.size = s.size
+	c.order = s.order
+	c.buf = bytes.NewBuffer(s.data)
+	c.runs = runs
+	return nil
+}
+
+// ReadChunk reads a chunk written by the WriteTo method of any compressed
+// chunk.
+func ReadChunk(rd io.Reader, codec VoxelCodec) (CompressedChunk, error) {
+	s, _, err := readChunk(rd, codecOrDefault(codec))
+	if err != nil {
+		return nil, err
+	}
+	var c interface {
+		CompressedChunk
+		load(s serialized) error
+	}
+	if s.magic == rleMagic {
+		c = &ChunkData{Codec: codec}
+	} else {
+		c = &PackedChunkData{Codec: codec}
+	}
+	if err := c.load(s); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// serialized is the content of a serialized chunk.
+type serialized struct {
+	magic   string
+	size    mgl.Vec3I
+	order   Order
+	palette []r.Voxel
+	data    []byte
+}
+
+// writeChunk writes the header, the palette and the voxel data of a chunk
+// followed by the checksum.
+func writeChunk(w io.Writer, codec VoxelCodec, s serialized) (int64, error) {
+	out := new(bytes.Buffer)
+	out.WriteString(s.magic)
+	out.WriteByte(fVersion)
+	out.WriteByte(codec.CodecID())
+	for _, v := range s.size {
+		out.Write(codeInt(uint(v)))
+	}
+	out.WriteByte(byte(s.order))
+
+	out.Write(codeInt(uint(len(s.palette))))
+	for _, vox := range s.palette {
+		if vox == nil {
+			out.WriteByte(0)
+			continue
+		}
+		out.WriteByte(1)
+		if err := codec.Encode(out, vox); err != nil {
+			return 0, err
+		}
+	}
+	out.Write(codeInt(uint(len(s.data))))
+	out.Write(s.data)
+
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(out.Bytes()))
+	out.Write(sum[:])
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// readChunk reads a chunk written by writeChunk and returns the number of
+// bytes read.
+func readChunk(rd io.Reader, codec VoxelCodec) (serialized, int64, error) {
+	cr := &chunkReader{rd: rd, crc: crc32.NewIEEE()}
+	s, err := cr.readChunk(codec)
+	if err == io.EOF && cr.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return s, cr.n, err
+}
+
+func (cr *chunkReader) readChunk(codec VoxelCodec) (serialized, error) {
+	var s serialized
+	var head [len(rleMagic) + 2]byte
+	if _, err := io.ReadFull(cr, head[:]); err != nil {
+		return s, err
+	}
+	s.magic = string(head[:len(rleMagic)])
+	if s.magic != rleMagic && s.magic != packedMagic {
+		return s, errors.New("rle: invalid chunk header")
+	}
+	version := head[len(rleMagic)]
+	if version < 1 || version > fVersion {
+		return s, fmt.Errorf("rle: unsupported version %v", version)
+	}
+	if id := head[len(rleMagic)+1]; id != codec.CodecID() {
+		return s, fmt.Errorf("rle: chunk was written with codec %v, got codec %v", id, codec.CodecID())
+	}
+
+	voxels := 1
+	for i := range s.size {
+		v, err := binary.ReadUvarint(cr)
+		if err != nil {
+			return s, err
+		}
+		if v == 0 || v > uint64(maxVolume/voxels) {
+			return s, errors.New("rle: invalid chunk size")
+		}
+		s.size[i] = int(v)
+		voxels *= s.size[i]
+	}
+	if version >= 2 {
+		o, err := cr.ReadByte()
+		if err != nil {
+			return s, err
+		}
+		if s.order = Order(o); s.order >= orderCount {
+			return s, fmt.Errorf("rle: invalid order %v", o)
+		}
+	}
+
+	palSize, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return s, err
+	}
+	if palSize > uint64(voxels) {
+		return s, fmt.Errorf("rle: invalid palette size %v", palSize)
+	}
+	for i := uint64(0); i < palSize; i++ {
+		flag, err := cr.ReadByte()
+		if err != nil {
+			return s, err
+		}
+		var vox r.Voxel
+		switch flag {
+		case 0:
+		case 1:
+			if vox, err = codec.Decode(cr); err != nil {
+				return s, err
+			}
+		default:
+			return s, errors.New("rle: invalid palette entry")
+		}
+		s.palette = append(s.palette, vox)
+	}
+
+	dataLen, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return s, err
+	}
+	if dataLen > uint64(voxels)*2*binary.MaxVarintLen64 {
+		return s, fmt.Errorf("rle: invalid data size %v", dataLen)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := io.CopyN(buf, cr, int64(dataLen)); err != nil {
+		return s, err
+	}
+	s.data = buf.Bytes()
+
+	expected := cr.crc.Sum32()
+	var sum [4]byte
+	if _, err := io.ReadFull(cr, sum[:]); err != nil {
+		return s, err
+	}
+	if binary.LittleEndian.Uint32(sum[:]) != expected {
+		return s, ErrChecksum
+	}
+	return s, nil
+}
+
+// indexRuns checks that the runs cover the chunk and only refer to existing
+// palette entries and returns the index of the runs.
+func indexRuns(d []byte, palSize, voxels int) ([]runInfo, error) {
+	var runs []runInfo
+	total, off := 0, 0
+	for off < len(d) {
+		idx, n := binary.Uvarint(d[off:])
+		if n <= 0 {
+			return nil, errors.New("rle: invalid run")
+		}
+		cnt, m := binary.Uvarint(d[off+n:])
+		if m <= 0 || cnt == 0 || idx >= uint64(palSize) || cnt > uint64(voxels-total) {
+			return nil, errors.New("rle: invalid run")
+		}
+		runs = append(runs, runInfo{total, off})
+		off += n + m
+		total += int(cnt)
+	}
+	if total != voxels {
+		return nil, fmt.Errorf("rle: runs cover %v of %v voxels", total, voxels)
+	}
+	return runs, nil
+}
+
+// chunkReader counts and checksums the bytes read from rd.
+type chunkReader struct {
+	rd  io.Reader
+	crc hash.Hash32
+	n   int64
+	b   [1]byte
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	n, err := cr.rd.Read(p)
+	cr.crc.Write(p[:n])
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *chunkReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(cr, cr.b[:])
+	return cr.b[0], err
+}
+
+type byteReader struct {
+	io.Reader
+	b [1]byte
+}
+
+func (br *byteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(br.Reader, br.b[:])
+	return br.b[0], err
+}
+
+func asByteReader(rd io.Reader) io.ByteReader {
+	if br, ok := rd.(io.ByteReader); ok {
+		return br
+	}
+	return &byteReader{Reader: rd}
+}
@@ -0,0 +1,169 @@
+package rle
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/boombuler/voxel/mgl"
+	r "github.com/boombuler/voxel/rendering"
+)
+
+var testCodec = NumericCodec{
+	ToID: func(vox r.Voxel) uint {
+		return uint(vox.(testVoxel))
+	},
+	FromID: func(id uint) r.Voxel {
+		return testVoxel(id)
+	},
+}
+
+func testChunk(size mgl.Vec3I, fn func(pos mgl.Vec3I) r.Voxel) *ChunkData {
+	ucd := NewUncompressedChunkData(size)
+	defer FreeUncompressedChunkData(&ucd)
+	for x := 0; x < size.X(); x++ {
+		for y := 0; y < size.Y(); y++ {
+			for z := 0; z < size.Z(); z++ {
+				pos := mgl.Vec3I{x, y, z}
+				ucd.Set(pos, fn(pos))
+			}
+		}
+	}
+	return ucd.Compress()
+}
+
+func compareChunks(t *testing.T, got, expected r.Chunk) {
+	size := expected.Size()
+	if !got.Size().Equals(size) {
+		t.Fatalf("Chunk has size %v expected %v", got.Size(), size)
+	}
+	for x := 0; x < size.X(); x++ {
+		for y := 0; y < size.Y(); y++ {
+			for z := 0; z < size.Z(); z++ {
+				pos := mgl.Vec3I{x, y, z}
+				if g, e := got.At(pos), expected.At(pos); g != e {
+					t.Fatalf("Voxel at %v is %v expected %v", pos, g, e)
+				}
+			}
+		}
+	}
+}
+
+func Test_SerializeNumeric(t *testing.T) {
+	for _, size := range testSizes {
+		cd := testChunk(size, func(pos mgl.Vec3I) r.Voxel {
+			if pos.Y() > 40 {
+				return nil
+			}
+			return testVoxel(((3 * pos.X()) + (17 * pos.Y()) + pos.Z()) % 5)
+		})
+		cd.Codec = testCodec
+
+		buf := new(bytes.Buffer)
+		n, err := cd.WriteTo(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("WriteTo returned %v bytes, wrote %v", n, buf.Len())
+		}
+		written := buf.Len()
+
+		res := &ChunkData{Codec: testCodec}
+		n, err = res.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(written) {
+			t.Errorf("ReadFrom returned %v bytes, expected %v", n, written)
+		}
+		compareChunks(t, res.Uncompress(), cd.Uncompress())
+	}
+}
+
+func Test_SerializeRGBA(t *testing.T) {
+	red, blue := RGBAVoxel{255, 0, 0, 255}, RGBAVoxel{0, 0, 255, 128}
+	cd := testChunk(DefaultChunkSize, func(pos mgl.Vec3I) r.Voxel {
+		switch (pos.X() + pos.Z()) % 3 {
+		case 0:
+			return red
+		case 1:
+			return blue
+		}
+		return nil
+	})
+
+	// several chunks have to be readable from one stream.
+	buf := new(bytes.Buffer)
+	for i := 0; i < 2; i++ {
+		if _, err := cd.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		res := new(ChunkData)
+		if _, err := res.ReadFrom(buf); err != nil {
+			t.Fatal(err)
+		}
+		compareChunks(t, res.Uncompress(), cd.Uncompress())
+	}
+	if _, err := new(ChunkData).ReadFrom(buf); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the stream, got %v", err)
+	}
+}
+
+func Test_SerializeCorrupted(t *testing.T) {
+	cd := testChunk(DefaultChunkSize, func(pos mgl.Vec3I) r.Voxel {
+		return testVoxel(pos.X() % 4)
+	})
+	cd.Codec = testCodec
+	buf := new(bytes.Buffer)
+	if _, err := cd.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-8] ^= 0x10
+	if _, err := (&ChunkData{Codec: testCodec}).ReadFrom(bytes.NewReader(flipped)); err != ErrChecksum {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+
+	if _, err := (&ChunkData{Codec: testCodec}).ReadFrom(bytes.NewReader(data[:len(data)-3])); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for truncated data, got %v", err)
+	}
+
+	version := append([]byte(nil), data...)
+	version[len(rleMagic)] = fVersion + 1
+	if _, err := (&ChunkData{Codec: testCodec}).ReadFrom(bytes.NewReader(version)); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+
+	if _, err := new(ChunkData).ReadFrom(bytes.NewReader(data)); err == nil {
+		t.Error("Expected error for codec mismatch")
+	}
+}
+
+func Test_SerializeAfterSet(t *testing.T) {
+	size := mgl.Vec3I{2, 2, 2}
+	cd := testChunk(size, func(pos mgl.Vec3I) r.Voxel {
+		return nil
+	})
+	cd.Codec = testCodec
+	for i := 0; i < 12; i++ {
+		cd.Set(idxToVec(size, i%volume(size)), testVoxel(i))
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := cd.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	res := &ChunkData{Codec: testCodec}
+	if _, err := res.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Palette) != volume(size) {
+		t.Errorf("Read palette has %v voxels expected %v", len(res.Palette), volume(size))
+	}
+	compareChunks(t, res, cd)
+}
@@ -6,44 +6,60 @@ import (
 	"sync"
 )
 
-var uncompressedChunkPool = sync.Pool{
-	New: func() interface{} {
-		return new(UncompressedChunkData)
-	},
-}
-
-func NewUncompressedChunkData() *UncompressedChunkData {
-	v, ok := uncompressedChunkPool.Get().(*UncompressedChunkData)
-	if ok {
-		return v
-	} else {
-		return new(UncompressedChunkData)
+// uncompressedChunkPools holds a *sync.Pool for every chunk size.
+var uncompressedChunkPools sync.Map
+
+func uncompressedChunkPool(size mgl.Vec3I) *sync.Pool {
+	if p, ok := uncompressedChunkPools.Load(size); ok {
+		return p.(*sync.Pool)
 	}
+	p, _ := uncompressedChunkPools.LoadOrStore(size, &sync.Pool{
+		New: func() interface{} {
+			return &UncompressedChunkData{
+				size: size,
+				data: make([]r.Voxel, volume(size)),
+			}
+		},
+	})
+	return p.(*sync.Pool)
+}
+
+// NewUncompressedChunkData returns empty chunk data of the given size. The data
+// is taken from a pool per size.
+func NewUncompressedChunkData(size mgl.Vec3I) *UncompressedChunkData {
+	return uncompressedChunkPool(size).Get().(*UncompressedChunkData)
 }
 
+// FreeUncompressedChunkData clears the chunk data and returns it to the pool.
 func FreeUncompressedChunkData(data **UncompressedChunkData) {
-	uncompressedChunkPool.Put(*data)
+	for i := range (*data).data {
+		(*data).data[i] = nil
+	}
+	uncompressedChunkPool((*data).size).Put(*data)
 	*data = nil
 }
 
-type UncompressedChunkData [ChunkSizeX * ChunkSizeY * ChunkSizeZ]r.Voxel
+type UncompressedChunkData struct {
+	size mgl.Vec3I
+	data []r.Voxel
+}
 
 func (u *UncompressedChunkData) ForeachVoxel(fn func(pos mgl.Vec3I, vox r.Voxel)) {
-	for i, vox := range u {
+	for i, vox := range u.data {
 		if vox != nil {
-			fn(idxToVec(i), vox)
+			fn(idxToVec(u.size, i), vox)
 		}
 	}
 }
 
 func (u *UncompressedChunkData) Set(pos mgl.Vec3I, vox r.Voxel) {
-	u[vecToIdx(pos)] = vox
+	u.data[vecToIdx(u.size, pos)] = vox
 }
 
 func (u *UncompressedChunkData) At(pos mgl.Vec3I) r.Voxel {
-	return u[vecToIdx(pos)]
+	return u.data[vecToIdx(u.size, pos)]
 }
 
 func (u *UncompressedChunkData) Size() mgl.Vec3I {
-	return mgl.Vec3I{ChunkSizeX, ChunkSizeY, ChunkSizeZ}
+	return u.size
 }
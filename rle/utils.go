@@ -4,19 +4,28 @@ import (
 	"github.com/boombuler/voxel/mgl"
 )
 
+// The dimensions of DefaultChunkSize.
 const ChunkSizeX = 64
 const ChunkSizeY = 64
 const ChunkSizeZ = 64
 
-func vecToIdx(v mgl.Vec3I) int {
-	return (((v.Z() * ChunkSizeY) + v.Y()) * ChunkSizeX) + v.X()
+// DefaultChunkSize is the size of chunks if there is no reason to use
+// another size.
+var DefaultChunkSize = mgl.Vec3I{ChunkSizeX, ChunkSizeY, ChunkSizeZ}
+
+func volume(size mgl.Vec3I) int {
+	return size.X() * size.Y() * size.Z()
+}
+
+func vecToIdx(size, v mgl.Vec3I) int {
+	return (((v.Z() * size.Y()) + v.Y()) * size.X()) + v.X()
 }
 
-func idxToVec(i int) mgl.Vec3I {
+func idxToVec(size mgl.Vec3I, i int) mgl.Vec3I {
 	return mgl.Vec3I{
-		i % ChunkSizeX,
-		(i / ChunkSizeX) % ChunkSizeY,
-		(i / (ChunkSizeX * ChunkSizeY)) % ChunkSizeZ,
+		i % size.X(),
+		(i / size.X()) % size.Y(),
+		(i / (size.X() * size.Y())) % size.Z(),
 	}
 }
 
@@ -25,21 +25,23 @@ func Test_CodeInt(t *testing.T) {
 }
 
 func Test_IntToVec_VecToInt(t *testing.T) {
-	idx_Exp := 0
-	for z := 0; z < ChunkSizeZ; z++ {
-		for y := 0; y < ChunkSizeY; y++ {
-			for x := 0; x < ChunkSizeX; x++ {
-				var vecTest = mgl.Vec3I{x, y, z}
-				idx := vecToIdx(vecTest)
-				if idx != idx_Exp {
-					t.Errorf("vecToIdx failed got %v expected %v", idx, idx_Exp)
-					return
-				}
-				idx_Exp++
+	for _, size := range testSizes {
+		idx_Exp := 0
+		for z := 0; z < size.Z(); z++ {
+			for y := 0; y < size.Y(); y++ {
+				for x := 0; x < size.X(); x++ {
+					var vecTest = mgl.Vec3I{x, y, z}
+					idx := vecToIdx(size, vecTest)
+					if idx != idx_Exp {
+						t.Errorf("vecToIdx failed got %v expected %v", idx, idx_Exp)
+						return
+					}
+					idx_Exp++
 
-				vec := idxToVec(idx)
-				if !vec.Equals(vecTest) {
-					t.Errorf("idxToVec failed got %v expected %v", vec, vecTest)
+					vec := idxToVec(size, idx)
+					if !vec.Equals(vecTest) {
+						t.Errorf("idxToVec failed got %v expected %v", vec, vecTest)
+					}
 				}
 			}
 		}